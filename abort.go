@@ -0,0 +1,29 @@
+package retry
+
+import "errors"
+
+// Abort wraps err so that Do stops retrying and returns err. Aborts are
+// recognized even if the result of Abort is itself wrapped.
+func Abort(err error) error {
+	if err == nil {
+		return nil
+	}
+	return abortError{err}
+}
+
+type abortError struct {
+	error
+}
+
+func (e abortError) Unwrap() error {
+	return e.error
+}
+
+// aborted returns the error passed to Abort if err is an abort.
+func aborted(err error) (error, bool) {
+	var abort abortError
+	if errors.As(err, &abort) {
+		return abort.error, true
+	}
+	return nil, false
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAbort(t *testing.T) {
+	t.Parallel()
+
+	fatal := errors.New("fatal")
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"direct", Abort(fatal)},
+		{"wrapped", fmt.Errorf("dial: %w", Abort(fatal))},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			err := Do(context.Background(), func(ctx context.Context) error {
+				calls++
+				return tc.err
+			}, WithFloor(time.Millisecond), WithCeil(time.Millisecond))
+			if calls != 1 {
+				t.Fatalf("got %d calls, want 1", calls)
+			}
+			if err != fatal {
+				t.Fatalf("got %v, want %v", err, fatal)
+			}
+		})
+	}
+}
+
+func TestAbort_Unwrap(t *testing.T) {
+	fatal := errors.New("fatal")
+	err := Abort(fatal)
+	if !errors.Is(err, fatal) {
+		t.Fatalf("abort does not unwrap to its error")
+	}
+	if err.Error() != fatal.Error() {
+		t.Fatalf("got message %q, want %q", err.Error(), fatal.Error())
+	}
+	if Abort(nil) != nil {
+		t.Fatalf("Abort(nil) is not nil")
+	}
+}
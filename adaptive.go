@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AdaptiveLimiter is a Limiter that slows attempts down when a destination
+// pushes back. It doesn't limit anything until an attempt is throttled; from
+// then on it paces attempts, cutting the rate on every throttle and raising
+// it again on every success until MaxRate is reached and pacing stops.
+// Static ceilings alone can't protect a shared dependency during a brownout.
+//
+// Do records the outcome of each attempt in it. It is safe for concurrent
+// use, and should be shared by all callers of the same destination; see
+// AdaptiveLimiters.
+type AdaptiveLimiter struct {
+	// MinRate and MaxRate bound the rate, in attempts per second.
+	MinRate, MaxRate float64
+
+	// Increase is how much each success raises the rate by.
+	Increase float64
+
+	// Throttled reports whether an error means the destination is
+	// throttling. If nil, errors carrying a retry-after delay (see After)
+	// count as throttling.
+	Throttled func(error) bool
+
+	// Clock, if set, is used in place of the system clock.
+	Clock Clock
+
+	mu sync.Mutex
+	// rate is the current rate, or zero if not limiting.
+	rate float64
+	// next is when the next attempt may be made.
+	next time.Time
+}
+
+// throttleBackoff is how much the rate is multiplied by on each throttle.
+const throttleBackoff = 0.7
+
+// NewAdaptiveLimiter creates a limiter whose rate ranges from min to max
+// attempts per second, climbing back by a twentieth of max per success.
+func NewAdaptiveLimiter(min, max float64) *AdaptiveLimiter {
+	return &AdaptiveLimiter{
+		MinRate:  min,
+		MaxRate:  max,
+		Increase: max / 20,
+	}
+}
+
+func (l *AdaptiveLimiter) clock() Clock {
+	if l.Clock == nil {
+		return systemClock{}
+	}
+	return l.Clock
+}
+
+// Rate returns the current rate in attempts per second, or zero if attempts
+// aren't being limited.
+func (l *AdaptiveLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Wait blocks until an attempt may be made at the current rate.
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	clock := l.clock()
+
+	l.mu.Lock()
+	if l.rate == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := clock.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	d := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	if d == 0 {
+		return nil
+	}
+	select {
+	case <-clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Record adjusts the rate after an attempt; a nil err is a success. Errors
+// that aren't throttling leave it alone.
+func (l *AdaptiveLimiter) Record(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err == nil {
+		if l.rate == 0 {
+			return
+		}
+		l.rate += l.Increase
+		if l.rate >= l.MaxRate {
+			// Fully recovered.
+			l.rate = 0
+		}
+		return
+	}
+
+	throttled := l.Throttled
+	if throttled == nil {
+		throttled = isThrottled
+	}
+	if !throttled(err) {
+		return
+	}
+	if l.rate == 0 {
+		l.rate = l.MaxRate
+	}
+	l.rate *= throttleBackoff
+	if l.rate < l.MinRate {
+		l.rate = l.MinRate
+	}
+}
+
+func isThrottled(err error) bool {
+	_, ok := RetryAfter(err)
+	return ok
+}
+
+// AdaptiveLimiters hands out an AdaptiveLimiter per destination, such as a
+// host name, so one struggling destination doesn't slow down the others.
+type AdaptiveLimiters struct {
+	// New creates the limiter for a destination.
+	New func() *AdaptiveLimiter
+
+	mu sync.Mutex
+	m  map[string]*AdaptiveLimiter
+}
+
+// For returns the limiter for dest, creating it if needed.
+func (ls *AdaptiveLimiters) For(dest string) *AdaptiveLimiter {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if l, ok := ls.m[dest]; ok {
+		return l
+	}
+	if ls.m == nil {
+		ls.m = make(map[string]*AdaptiveLimiter)
+	}
+	l := ls.New()
+	ls.m[dest] = l
+	return l
+}
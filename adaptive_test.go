@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	clock := newTestClock()
+	l := NewAdaptiveLimiter(1, 10)
+	l.Increase = 2
+	l.Clock = clock
+	ctx := context.Background()
+
+	// Nothing is limited until the first throttle.
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Record(errors.New("not throttling"))
+	if clock.Elapsed() != 0 || l.Rate() != 0 {
+		t.Fatalf("limited before throttling: waited %v, rate %v", clock.Elapsed(), l.Rate())
+	}
+
+	throttle := After(errors.New("slow down"), time.Second)
+	l.Record(throttle)
+	if got := l.Rate(); got != 7 {
+		t.Fatalf("got rate %v, want 7", got)
+	}
+	l.Record(throttle)
+	l.Record(throttle)
+	l.Record(throttle)
+	l.Record(throttle)
+	l.Record(throttle)
+	l.Record(throttle)
+	if got := l.Rate(); got != 1 {
+		t.Fatalf("got rate %v, want MinRate", got)
+	}
+
+	// At one attempt per second, the first goes straight away and the
+	// rest are spaced out.
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := clock.Elapsed(); got != 2*time.Second {
+		t.Fatalf("waited %v, want 2s", got)
+	}
+
+	// Successes recover the rate until pacing stops.
+	for i := 0; i < 5; i++ {
+		l.Record(nil)
+	}
+	if got := l.Rate(); got != 0 {
+		t.Fatalf("got rate %v after recovering, want 0", got)
+	}
+}
+
+func TestAdaptiveLimiter_Do(t *testing.T) {
+	t.Parallel()
+
+	var ls AdaptiveLimiters
+	ls.New = func() *AdaptiveLimiter { return NewAdaptiveLimiter(100, 1000) }
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return After(errors.New("slow down"), time.Millisecond)
+		}
+		return nil
+	},
+		WithFloor(time.Millisecond),
+		WithCeil(time.Millisecond),
+		func(r *Retrier) { r.Limiter = ls.For("a") },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The throttle engaged and the success recovered.
+	if got := ls.For("a").Rate(); got != 750 {
+		t.Fatalf("got rate %v, want 750", got)
+	}
+	if ls.For("b") == ls.For("a") {
+		t.Fatal("destinations share a limiter")
+	}
+}
@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"errors"
+	"time"
+)
+
+// After wraps err so that, when returned by a function run by Do, the next
+// attempt is made after d instead of the backoff delay, even if d is beyond
+// the retrier's Ceil. It is meant for APIs that say how long to wait before
+// trying again, which are never retried sooner than they ask. Do gives up
+// with ErrDeadlineExceeded rather than wait past MaxElapsed.
+func After(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return afterError{error: err, d: d}
+}
+
+type afterError struct {
+	error
+	d time.Duration
+}
+
+func (e afterError) Unwrap() error {
+	return e.error
+}
+
+// RetryAfter returns the delay requested by After anywhere in err's chain.
+func RetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		// Spare errors.As, whose target escapes, on the common path.
+		return 0, false
+	}
+	var after afterError
+	if errors.As(err, &after) {
+		return after.d, true
+	}
+	return 0, false
+}
+
+// after replaces d, the delay next chose, with the one After requested in
+// err, if any, subject to r's MinDelay and MaxElapsed.
+func (r *Retrier) after(err error, d time.Duration) (time.Duration, error) {
+	after, ok := RetryAfter(err)
+	if !ok {
+		return d, nil
+	}
+	if after < r.MinDelay {
+		after = r.MinDelay
+	}
+	if after < 0 {
+		after = 0
+	}
+	now := r.clock().Now()
+	// Compare durations, as adding a huge delay to now could overflow.
+	if r.MaxElapsed > 0 && after > r.MaxElapsed-now.Sub(r.start) {
+		return 0, ErrDeadlineExceeded
+	}
+	r.Delay = after
+	r.wake = now.Add(after)
+	return after, nil
+}
@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	throttled := errors.New("throttled")
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return fmt.Errorf("wrapped: %w", After(throttled, time.Second*30))
+		case 2:
+			return throttled
+		}
+		return nil
+	}, WithFloor(time.Second), WithCeil(time.Minute), WithRate(2), func(r *Retrier) { r.Clock = clock })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 30s as requested, then the regular 4s backoff.
+	if got, want := clock.Elapsed(), time.Second*34; got != want {
+		t.Fatalf("slept %v, want %v", got, want)
+	}
+}
+
+func TestAfter_BeyondCeil(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return After(errors.New("throttled"), time.Minute*2)
+		}
+		return nil
+	}, WithFloor(time.Second), WithCeil(time.Second*10), func(r *Retrier) { r.Clock = clock })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Never sooner than asked, whatever Ceil says.
+	if got, want := clock.Elapsed(), time.Minute*2; got != want {
+		t.Fatalf("slept %v, want %v", got, want)
+	}
+}
+
+func TestAfter_MaxElapsed(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	throttled := errors.New("throttled")
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return After(throttled, time.Duration(math.MaxInt64))
+	}, WithFloor(time.Second), WithCeil(time.Hour), WithMaxElapsed(time.Second*30), func(r *Retrier) { r.Clock = clock })
+	if !errors.Is(err, throttled) {
+		t.Fatalf("got %v, want %v", err, throttled)
+	}
+	if calls != 1 || clock.Elapsed() != 0 {
+		t.Fatalf("made %d calls over %v, want 1 call and no sleep", calls, clock.Elapsed())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := After(errors.New("slow down"), time.Minute)
+	if d, ok := RetryAfter(fmt.Errorf("wrapped: %w", err)); !ok || d != time.Minute {
+		t.Fatalf("got (%v, %v), want (%v, true)", d, ok, time.Minute)
+	}
+	if _, ok := RetryAfter(errors.New("plain")); ok {
+		t.Fatalf("plain error has a delay")
+	}
+	if After(nil, time.Minute) != nil {
+		t.Fatalf("After(nil) is not nil")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package retry
+
+import (
+	"context"
+	"iter"
+)
+
+// Attempts returns an iterator that yields each time Wait allows another
+// attempt. The values are attempt numbers, starting at 1:
+//
+//	for attempt := range r.Attempts(ctx) {
+//		err = dial()
+//		if err == nil {
+//			break
+//		}
+//		log.Printf("attempt %d: %v", attempt, err)
+//	}
+func (r *Retrier) Attempts(ctx context.Context) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for n := 1; r.Wait(ctx); n++ {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
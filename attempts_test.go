@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := New(time.Millisecond, time.Millisecond)
+
+	var got []int
+	for attempt := range r.Attempts(ctx) {
+		got = append(got, attempt)
+		if attempt == 3 {
+			break
+		}
+	}
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("unexpected attempts: %v", got)
+	}
+
+	cancel()
+	for range r.Attempts(ctx) {
+		t.Fatalf("attempt allowed even though context cancelled")
+	}
+}
@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes a single attempt of an audited operation.
+type AuditRecord struct {
+	// Time is when the attempt started.
+	Time time.Time
+	// Target names the operation, e.g. "charge-card".
+	Target string
+	// Attempt counts the attempts made so far, starting at 1.
+	Attempt int
+	// Err is the outcome of the attempt; nil means success.
+	Err error
+}
+
+// AuditSink receives audit records. Append is called synchronously after
+// each attempt, so slow sinks slow down the retry loop.
+type AuditSink interface {
+	Append(AuditRecord)
+}
+
+// AuditSinkFunc adapts a function to an AuditSink.
+type AuditSinkFunc func(AuditRecord)
+
+// Append calls f(rec).
+func (f AuditSinkFunc) Append(rec AuditRecord) {
+	f(rec)
+}
+
+// Audit wraps fn so that every call to it is appended to sink as an attempt
+// against target. The returned function is meant to be called once per
+// attempt of a single run of the operation:
+//
+//	charge := retry.Audit(sink, "charge-card", chargeCard)
+//	for r.Wait(ctx) {
+//		if err = charge(ctx); err == nil {
+//			break
+//		}
+//	}
+func Audit(sink AuditSink, target string, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	var (
+		mu      sync.Mutex
+		attempt int
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		attempt++
+		n := attempt
+		mu.Unlock()
+
+		start := time.Now()
+		err := fn(ctx)
+		sink.Append(AuditRecord{
+			Time:    start,
+			Target:  target,
+			Attempt: n,
+			Err:     err,
+		})
+		return err
+	}
+}
+
+// JSONAuditSink writes audit records to an io.Writer as JSON, one per line.
+type JSONAuditSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJSONAuditSink creates an AuditSink writing to w.
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{w: w}
+}
+
+type jsonAuditRecord struct {
+	Time    time.Time `json:"time"`
+	Target  string    `json:"target"`
+	Attempt int       `json:"attempt"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Append writes rec. Write errors are retained and reported by Err; once one
+// occurs, subsequent records are dropped.
+func (s *JSONAuditSink) Append(rec AuditRecord) {
+	jrec := jsonAuditRecord{
+		Time:    rec.Time,
+		Target:  rec.Target,
+		Attempt: rec.Attempt,
+		Outcome: "success",
+	}
+	if rec.Err != nil {
+		jrec.Outcome = "failure"
+		jrec.Error = rec.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	b, err := json.Marshal(jrec)
+	if err != nil {
+		s.err = err
+		return
+	}
+	_, s.err = s.w.Write(append(b, '\n'))
+}
+
+// Err returns the first error encountered while writing records.
+func (s *JSONAuditSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	var recs []AuditRecord
+	sink := AuditSinkFunc(func(rec AuditRecord) {
+		recs = append(recs, rec)
+	})
+
+	fails := 2
+	op := Audit(sink, "provision", func(ctx context.Context) error {
+		if fails > 0 {
+			fails--
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	r := New(time.Millisecond, time.Millisecond)
+	for r.Wait(ctx) {
+		if op(ctx) == nil {
+			break
+		}
+	}
+
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, want 3", len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Target != "provision" {
+			t.Errorf("record %d: target %q", i, rec.Target)
+		}
+		if rec.Attempt != i+1 {
+			t.Errorf("record %d: attempt %d", i, rec.Attempt)
+		}
+		if rec.Time.IsZero() {
+			t.Errorf("record %d: zero time", i)
+		}
+		if (rec.Err == nil) != (i == 2) {
+			t.Errorf("record %d: unexpected outcome %v", i, rec.Err)
+		}
+	}
+}
+
+func TestJSONAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONAuditSink(&buf)
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.Append(AuditRecord{Time: now, Target: "pay", Attempt: 1, Err: errors.New("timeout")})
+	sink.Append(AuditRecord{Time: now, Target: "pay", Attempt: 2})
+	if err := sink.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var got []map[string]interface{}
+	for dec.More() {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d lines, want 2", len(got))
+	}
+	if got[0]["outcome"] != "failure" || got[0]["error"] != "timeout" {
+		t.Errorf("unexpected first record: %v", got[0])
+	}
+	if got[1]["outcome"] != "success" || got[1]["attempt"] != 2.0 {
+		t.Errorf("unexpected second record: %v", got[1])
+	}
+}
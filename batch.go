@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBatchIncomplete is returned by Batch when some items never succeeded.
+var ErrBatchIncomplete = errors.New("retry: batch incomplete")
+
+// Batch retries a batch operation, such as a bulk insert, on the items that
+// failed until none are left or r gives up. fn returns the subset of items
+// it was given that failed. If it returns an error without saying which
+// items failed, they all did.
+//
+// Batch returns the items that never succeeded along with the last error
+// from fn, or an error wrapping ErrBatchIncomplete if fn's last call only
+// partially failed. fn can stop the loop early by returning an error
+// wrapped with Abort, or one that reports itself as not Retryable. r is
+// Reset first.
+func Batch[T any](ctx context.Context, r *Retrier, items []T, fn func(ctx context.Context, items []T) (failed []T, err error)) ([]T, error) {
+	pending := items
+	err := r.Do(ctx, func(ctx context.Context) error {
+		failed, err := fn(ctx, pending)
+		if err != nil {
+			if len(failed) > 0 {
+				pending = failed
+			}
+			return err
+		}
+		pending = failed
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: %d of %d items failed", ErrBatchIncomplete, len(pending), len(items))
+		}
+		return nil
+	})
+	if err != nil {
+		return pending, err
+	}
+	return nil, nil
+}
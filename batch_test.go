@@ -0,0 +1,60 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	var calls [][]int
+	failed, err := Batch(context.Background(), r, []int{1, 2, 3, 4}, func(ctx context.Context, items []int) ([]int, error) {
+		calls = append(calls, items)
+		switch len(calls) {
+		case 1:
+			return []int{2, 4}, nil
+		case 2:
+			return nil, errors.New("whole call failed")
+		case 3:
+			return []int{4}, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != nil {
+		t.Fatalf("got failed items %v", failed)
+	}
+	want := [][]int{{1, 2, 3, 4}, {2, 4}, {2, 4}, {4}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestBatch_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+	failed, err := Batch(context.Background(), r, []string{"a", "b"}, func(ctx context.Context, items []string) ([]string, error) {
+		var failed []string
+		for _, item := range items {
+			if item == "b" {
+				failed = append(failed, item)
+			}
+		}
+		return failed, nil
+	})
+	if !errors.Is(err, ErrBatchIncomplete) {
+		t.Fatalf("got %v, want %v", err, ErrBatchIncomplete)
+	}
+	if !reflect.DeepEqual(failed, []string{"b"}) {
+		t.Fatalf("got failed items %v, want [b]", failed)
+	}
+}
@@ -0,0 +1,160 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned when a Breaker rejects an attempt.
+var ErrBreakerOpen = errors.New("retry: circuit breaker open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all attempts through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all attempts until its cool-down has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial attempt through, whose outcome
+	// decides whether the breaker closes or opens again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker is a circuit breaker. Once the share of failed attempts reaches
+// FailureRate, it opens and rejects attempts for Cooldown, sparing a
+// struggling dependency. It is safe for concurrent use, and is typically
+// shared by every Retrier talking to the same dependency.
+type Breaker struct {
+	// FailureRate is the share of failed attempts, from 0 to 1, at which the
+	// breaker opens.
+	FailureRate float64
+
+	// MinAttempts is how many attempts must be recorded before the failure
+	// rate is considered.
+	MinAttempts int
+
+	// Window, if non-zero, is how long attempts are counted for while the
+	// breaker is closed. Zero counts every attempt since it last closed.
+	Window time.Duration
+
+	// Cooldown is how long the breaker stays open before allowing a trial
+	// attempt.
+	Cooldown time.Duration
+
+	// Clock, if set, is used in place of the system clock.
+	Clock Clock
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	total    int
+	// since is when counting started if closed, or when the breaker
+	// opened otherwise.
+	since time.Time
+}
+
+// NewBreaker creates a breaker that opens once at least minAttempts attempts
+// have been recorded and the share of them that failed reaches failureRate,
+// and stays open for cooldown.
+func NewBreaker(failureRate float64, minAttempts int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		FailureRate: failureRate,
+		MinAttempts: minAttempts,
+		Cooldown:    cooldown,
+	}
+}
+
+func (b *Breaker) now() time.Time {
+	if b.Clock == nil {
+		return time.Now()
+	}
+	return b.Clock.Now()
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.now().Before(b.since.Add(b.Cooldown)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow reports whether an attempt may go ahead, returning ErrBreakerOpen if
+// not. Once the cool-down has passed, a single caller is allowed through to
+// make a trial attempt, whose outcome must be recorded.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.since.Add(b.Cooldown)) {
+			return ErrBreakerOpen
+		}
+		b.state = BreakerHalfOpen
+		return nil
+	case BreakerHalfOpen:
+		// A trial attempt is already in flight.
+		return ErrBreakerOpen
+	}
+	return nil
+}
+
+// Record records the outcome of an attempt; a nil err is a success.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case BreakerHalfOpen:
+		if err == nil {
+			b.close(now)
+		} else {
+			b.open(now)
+		}
+		return
+	case BreakerOpen:
+		// Attempts allowed before the breaker opened don't count.
+		return
+	}
+
+	if b.Window > 0 && !now.Before(b.since.Add(b.Window)) {
+		b.close(now)
+	}
+	b.total++
+	if err != nil {
+		b.failures++
+	}
+	if b.total >= b.MinAttempts && float64(b.failures) >= b.FailureRate*float64(b.total) {
+		b.open(now)
+	}
+}
+
+func (b *Breaker) open(now time.Time) {
+	b.state = BreakerOpen
+	b.since = now
+}
+
+func (b *Breaker) close(now time.Time) {
+	b.state = BreakerClosed
+	b.since = now
+	b.failures = 0
+	b.total = 0
+}
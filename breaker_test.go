@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	clock := newTestClock()
+	b := NewBreaker(0.5, 4, time.Minute)
+	b.Clock = clock
+
+	fail := errors.New("fail")
+	for _, err := range []error{nil, fail, nil} {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("closed breaker rejected attempt: %v", err)
+		}
+		b.Record(err)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Fatalf("breaker %v before reaching MinAttempts", s)
+	}
+
+	b.Record(fail)
+	if s := b.State(); s != BreakerOpen {
+		t.Fatalf("breaker %v at 50%% failures", s)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("open breaker allowed attempt: %v", err)
+	}
+
+	// After the cool-down, one trial goes through.
+	clock.Advance(time.Minute)
+	if s := b.State(); s != BreakerHalfOpen {
+		t.Fatalf("breaker %v after cool-down", s)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("trial attempt rejected: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("second trial attempt allowed")
+	}
+
+	// A failed trial opens it again.
+	b.Record(fail)
+	if err := b.Allow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("breaker did not reopen after failed trial")
+	}
+
+	// A successful trial closes it.
+	clock.Advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("trial attempt rejected: %v", err)
+	}
+	b.Record(nil)
+	if s := b.State(); s != BreakerClosed {
+		t.Fatalf("breaker %v after successful trial", s)
+	}
+}
+
+func TestBreaker_Window(t *testing.T) {
+	clock := newTestClock()
+	b := NewBreaker(0.5, 2, time.Minute)
+	b.Window = time.Second
+	b.Clock = clock
+
+	fail := errors.New("fail")
+	b.Record(fail)
+	clock.Advance(time.Second)
+	// The old failure has left the window.
+	b.Record(nil)
+	b.Record(nil)
+	if s := b.State(); s != BreakerClosed {
+		t.Fatalf("breaker %v counted a failure outside the window", s)
+	}
+}
+
+func TestBreaker_Do(t *testing.T) {
+	t.Parallel()
+
+	b := NewBreaker(1, 2, time.Hour)
+	fail := errors.New("fail")
+
+	var calls int
+	fn := func(ctx context.Context) error {
+		calls++
+		return fail
+	}
+	opts := []Option{
+		WithFloor(time.Millisecond),
+		WithCeil(time.Millisecond),
+		WithMaxAttempts(5),
+		func(r *Retrier) { r.Breaker = b },
+	}
+
+	if err := Do(context.Background(), fn, opts...); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+
+	// Later calls fail fast.
+	if err := Do(context.Background(), fn, opts...); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("got %v, want %v", err, ErrBreakerOpen)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExhausted is returned when a Budget has no tokens left for a retry.
+var ErrBudgetExhausted = errors.New("retry: retry budget exhausted")
+
+// Budget is a token bucket shared by many retriers that caps how much extra
+// traffic retries can add. Every retry takes a token and every success puts
+// back a fraction of one, so during an outage retries die out instead of
+// multiplying the load on the failing dependency. First attempts are never
+// limited. It is safe for concurrent use.
+type Budget struct {
+	// Max is the number of tokens in a full bucket, and so the number of
+	// retries allowed in a burst.
+	Max float64
+
+	// Ratio is the number of tokens each success returns. E.g. 0.1 allows
+	// roughly one retry for every ten successes once the bucket is empty.
+	Ratio float64
+
+	mu sync.Mutex
+	// spent is how many tokens are missing from a full bucket.
+	spent float64
+}
+
+// NewBudget creates a full budget of max tokens that regains ratio tokens
+// for every success.
+func NewBudget(max, ratio float64) *Budget {
+	return &Budget{Max: max, Ratio: ratio}
+}
+
+// Tokens returns the number of tokens left.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Max - b.spent
+}
+
+// Withdraw takes a token for a retry, reporting whether one was available.
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Max-b.spent < 1 {
+		return false
+	}
+	b.spent++
+	return true
+}
+
+// Deposit records a success, returning Ratio tokens to the bucket.
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent -= b.Ratio
+	if b.spent < 0 {
+		b.spent = 0
+	}
+}
@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(2, 0.5)
+	if !b.Withdraw() || !b.Withdraw() {
+		t.Fatal("full budget refused a withdrawal")
+	}
+	if b.Withdraw() {
+		t.Fatal("empty budget allowed a withdrawal")
+	}
+
+	b.Deposit()
+	if b.Withdraw() {
+		t.Fatal("half a token allowed a withdrawal")
+	}
+	b.Deposit()
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Fatal("refilled budget refused a withdrawal")
+	}
+
+	for i := 0; i < 10; i++ {
+		b.Deposit()
+	}
+	if got := b.Tokens(); got != 2 {
+		t.Fatalf("got %v tokens, want no more than Max", got)
+	}
+}
+
+func TestBudget_Do(t *testing.T) {
+	t.Parallel()
+
+	b := NewBudget(3, 1)
+	fail := errors.New("fail")
+	opts := []Option{
+		WithFloor(time.Millisecond),
+		WithCeil(time.Millisecond),
+		WithMaxAttempts(10),
+		func(r *Retrier) { r.Budget = b },
+	}
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return fail
+	}, opts...)
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	// The first attempt is free; then the three tokens allow three retries.
+	if calls != 4 {
+		t.Fatalf("got %d calls, want 4", calls)
+	}
+
+	// With the budget spent, first attempts still go ahead, and their
+	// successes refill it.
+	err = Do(context.Background(), func(ctx context.Context) error { return nil }, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Tokens(); got != 1 {
+		t.Fatalf("got %v tokens, want 1", got)
+	}
+}
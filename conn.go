@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Conn is a net.Conn that transparently re-dials when its connection breaks.
+//
+// Data in flight when the connection broke is lost: bytes the peer never
+// read are not resent, and bytes it sent but were never received are gone.
+// Conn suits protocols that can resume from any message boundary, or that
+// re-establish their state on reconnect.
+type Conn struct {
+	dial        func(ctx context.Context) (net.Conn, error)
+	r           *Retrier
+	onReconnect func(err error)
+
+	// fresh is set while the current connection has carried no data. The
+	// backoff is only reset once it does, so a peer that hangs up straight
+	// away doesn't cause a storm of re-dials.
+	fresh atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	conn          net.Conn
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+// NewConn dials a connection with dial, retrying according to a clone of r,
+// and returns a Conn that re-dials the same way whenever Read or Write hit a
+// connection error. The backoff carries on across re-dials until a
+// connection carries data. onReconnect, if not nil, is called after each
+// successful re-dial with the error that broke the previous connection.
+func NewConn(ctx context.Context, dial func(ctx context.Context) (net.Conn, error), r *Retrier, onReconnect func(err error)) (*Conn, error) {
+	r = r.Clone()
+	conn, err := FuncCtx[net.Conn](dial).Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	cctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		dial:        dial,
+		r:           r,
+		onReconnect: onReconnect,
+		ctx:         cctx,
+		cancel:      cancel,
+		conn:        conn,
+	}
+	c.fresh.Store(true)
+	return c, nil
+}
+
+// carried notes that the current connection carried data, so the next
+// outage starts backing off afresh.
+func (c *Conn) carried() {
+	if c.fresh.CompareAndSwap(true, false) {
+		c.mu.Lock()
+		c.r.Reset()
+		c.mu.Unlock()
+	}
+}
+
+func (c *Conn) current() net.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+// Read reads from the current connection, re-dialing if it's broken.
+func (c *Conn) Read(p []byte) (int, error) {
+	for {
+		conn := c.current()
+		n, err := conn.Read(p)
+		if n > 0 {
+			c.carried()
+		}
+		if n > 0 || err == nil || !isBroken(err) {
+			return n, err
+		}
+		if err := c.reconnect(conn, err); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write writes to the current connection, re-dialing and writing whatever
+// remains if it's broken.
+func (c *Conn) Write(p []byte) (int, error) {
+	var written int
+	for {
+		conn := c.current()
+		n, err := conn.Write(p[written:])
+		written += n
+		if n > 0 {
+			c.carried()
+		}
+		if err == nil || !isBroken(err) {
+			return written, err
+		}
+		if err := c.reconnect(conn, err); err != nil {
+			return written, err
+		}
+	}
+}
+
+// reconnect replaces broken, which failed with cause, with a new connection,
+// unless another goroutine has already done so.
+func (c *Conn) reconnect(broken net.Conn, cause error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != broken {
+		return nil
+	}
+	_ = broken.Close()
+
+	var conn net.Conn
+	err := c.r.resume(c.ctx, func(ctx context.Context) error {
+		var err error
+		conn, err = c.dial(ctx)
+		return err
+	})
+	if err != nil {
+		return cause
+	}
+	c.fresh.Store(true)
+	_ = conn.SetReadDeadline(c.readDeadline)
+	_ = conn.SetWriteDeadline(c.writeDeadline)
+	c.conn = conn
+
+	if c.onReconnect != nil {
+		c.onReconnect(cause)
+	}
+	return nil
+}
+
+// Close closes the current connection and stops any re-dial in progress.
+func (c *Conn) Close() error {
+	c.cancel()
+	return c.current().Close()
+}
+
+// LocalAddr returns the local address of the current connection.
+func (c *Conn) LocalAddr() net.Addr { return c.current().LocalAddr() }
+
+// RemoteAddr returns the remote address of the current connection.
+func (c *Conn) RemoteAddr() net.Addr { return c.current().RemoteAddr() }
+
+// SetDeadline sets the read and write deadlines, which carry over to new
+// connections.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline, c.writeDeadline = t, t
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline, which carries over to new
+// connections.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline, which carries over to new
+// connections.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeDeadline = t
+	return c.conn.SetWriteDeadline(t)
+}
+
+// isBroken reports whether err means the connection is gone.
+func isBroken(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
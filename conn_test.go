@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConn(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Each connection gets a greeting and is then hung up on.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "hi\n")
+			conn.Close()
+		}
+	}()
+
+	var (
+		dials      int
+		reconnects []error
+	)
+	dial := func(ctx context.Context) (net.Conn, error) {
+		dials++
+		if dials == 2 {
+			return nil, errors.New("flaky")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", l.Addr().String())
+	}
+
+	ctx := context.Background()
+	c, err := NewConn(ctx, dial, New(time.Millisecond, time.Millisecond), func(err error) {
+		reconnects = append(reconnects, err)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Reading past the first greeting hits EOF and reconnects.
+	r := bufio.NewReader(c)
+	for i := 0; i < 2; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "hi\n" {
+			t.Fatalf("got %q, want %q", line, "hi\n")
+		}
+	}
+
+	if dials != 3 {
+		t.Fatalf("got %d dials, want 3", dials)
+	}
+	if len(reconnects) != 1 || !errors.Is(reconnects[0], io.EOF) {
+		t.Fatalf("got reconnects %v, want one caused by EOF", reconnects)
+	}
+}
+
+func TestConn_HangUp(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	var dials int
+	dial := func(ctx context.Context) (net.Conn, error) {
+		dials++
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	r := New(time.Second, time.Minute)
+	r.Rate = 2
+	r.MaxAttempts = 4
+	r.Clock = clock
+	c, err := NewConn(context.Background(), dial, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// A peer that hangs up without a word doesn't reset the backoff.
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+	if dials != 4 {
+		t.Fatalf("got %d dials, want 4", dials)
+	}
+	if got, want := clock.Elapsed(), 14*time.Second; got != want {
+		t.Fatalf("backed off for %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+)
+
+// Coordinator spreads out the attempts of many processes retrying the same
+// dependency, beyond what each process's own jitter can do. An
+// implementation might hand out slots from a shared store such as Redis.
+type Coordinator interface {
+	// Schedule returns when an attempt due at t should actually be made,
+	// which must not be before t.
+	Schedule(ctx context.Context, t time.Time) (time.Time, error)
+}
+
+// HashCoordinator is a Coordinator that needs no communication. It hashes ID,
+// which should be unique to the process, to a fixed offset within Period,
+// and delays each attempt to the next time the wall clock reaches that
+// offset. Processes with different IDs thus settle into different slots.
+type HashCoordinator struct {
+	ID     string
+	Period time.Duration
+}
+
+var _ Coordinator = HashCoordinator{}
+
+// Schedule implements Coordinator.
+func (c HashCoordinator) Schedule(ctx context.Context, t time.Time) (time.Time, error) {
+	if c.Period <= 0 {
+		return t, nil
+	}
+	h := fnv.New64a()
+	h.Write([]byte(c.ID))
+	offset := time.Duration(h.Sum64() % uint64(c.Period))
+
+	slot := t.Truncate(c.Period).Add(offset)
+	if slot.Before(t) {
+		slot = slot.Add(c.Period)
+	}
+	return slot, nil
+}
@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHashCoordinator(t *testing.T) {
+	ctx := context.Background()
+	base := time.Unix(1000, 0)
+
+	slots := make(map[time.Duration]bool)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		c := HashCoordinator{ID: id, Period: time.Second}
+		at, err := c.Schedule(ctx, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := at.Sub(base)
+		if d < 0 || d >= time.Second {
+			t.Fatalf("%s: slot %v outside the period", id, d)
+		}
+		slots[d] = true
+
+		// The slot is stable.
+		again, _ := c.Schedule(ctx, base.Add(d))
+		if !again.Equal(at) {
+			t.Fatalf("%s: slot moved from %v to %v", id, at, again)
+		}
+	}
+	if len(slots) < 2 {
+		t.Fatal("all processes got the same slot")
+	}
+}
+
+func TestCoordinator_Retrier(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Second)
+	r.Clock = clock
+	r.Coordinator = HashCoordinator{ID: "a", Period: time.Minute}
+
+	ctx := context.Background()
+	r.Wait(ctx)
+	if got := clock.Elapsed(); got != 0 {
+		t.Fatalf("first attempt waited %v", got)
+	}
+	r.Wait(ctx)
+	second := clock.Elapsed()
+	if second < time.Second || second >= time.Minute+time.Second {
+		t.Fatalf("second attempt waited %v", second)
+	}
+	r.Wait(ctx)
+	// The next retry lands in the same slot of the next period.
+	if got := clock.Elapsed() - second; got != time.Minute {
+		t.Fatalf("third attempt waited %v, want 1m", got)
+	}
+}
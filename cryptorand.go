@@ -0,0 +1,43 @@
+package retry
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+// CryptoSource is a math/rand.Source that draws from crypto/rand. Unlike
+// math/rand's own sources, it can't end up seeded the same in processes
+// started together from one image, which would correlate their jitter and
+// defeat its purpose. Seed does nothing. It is safe for concurrent use.
+type CryptoSource struct{}
+
+var _ rand.Source64 = CryptoSource{}
+
+// Uint64 returns a random uint64.
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("retry: crypto/rand: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Int63 returns a non-negative random int64.
+func (s CryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed does nothing, as crypto/rand can't be seeded.
+func (CryptoSource) Seed(int64) {}
+
+// WithRand sets the source of jitter. See Retrier.Rand. A seeded source
+// makes jittered delays repeatable in tests.
+func WithRand(rng *rand.Rand) Option {
+	return func(r *Retrier) { r.Rand = rng }
+}
+
+// WithCryptoJitter makes jitter come from crypto/rand. See CryptoSource.
+func WithCryptoJitter() Option {
+	return func(r *Retrier) { r.Rand = rand.New(CryptoSource{}) }
+}
@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestCryptoSource(t *testing.T) {
+	// Two sources seeded identically still differ.
+	a, b := rand.New(CryptoSource{}), rand.New(CryptoSource{})
+	a.Seed(1)
+	b.Seed(1)
+	if a.Int63() == b.Int63() && a.Int63() == b.Int63() {
+		t.Fatal("crypto sources produced the same sequence")
+	}
+	if (CryptoSource{}).Int63() < 0 {
+		t.Fatal("Int63 returned a negative number")
+	}
+}
+
+func TestWithCryptoJitter(t *testing.T) {
+	r := NewRetrier(WithCryptoJitter())
+	if r.Rand == nil {
+		t.Fatal("Rand not set")
+	}
+	r.Jitter = 0.1
+	r.Floor, r.Ceil = time.Second, time.Minute
+	for i := 0; i < 10; i++ {
+		d, err := r.next()
+		if err != nil || d < 0 || d > r.Ceil {
+			t.Fatalf("got delay %v, err %v", d, err)
+		}
+	}
+}
+
+func TestWithRand_Clones(t *testing.T) {
+	// Clones share Rand; run under -race to check their draws are serialized.
+	r := NewRetrier(WithRand(rand.New(rand.NewSource(1))), WithJitter(0.5))
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(c *Retrier) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 100; j++ {
+				c.applyJitter(time.Second)
+			}
+		}(r.Clone())
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+}
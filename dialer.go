@@ -0,0 +1,61 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Dialer dials connections, retrying errors that suggest the other end isn't
+// up yet. It suits services that race their dependencies at startup.
+type Dialer struct {
+	// Dialer makes the individual attempts.
+	net.Dialer
+
+	// Retrier is cloned for every dial. If nil, dials back off from 100ms to
+	// 5s until the context passed to DialContext is done.
+	Retrier *Retrier
+
+	// ShouldRetry decides whether a failed dial is retried. If nil,
+	// connection refused, connection reset and timeout errors are.
+	ShouldRetry func(err error) bool
+}
+
+// DialContext is like net.Dialer.DialContext, but retries failed dials.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	r := d.Retrier
+	if r == nil {
+		r = New(time.Millisecond*100, time.Second*5)
+	} else {
+		r = r.Clone()
+	}
+	shouldRetry := d.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = isDialRetryable
+	}
+
+	return FuncCtx[net.Conn](func(ctx context.Context) (net.Conn, error) {
+		conn, err := d.Dialer.DialContext(ctx, network, address)
+		if err != nil && !shouldRetry(err) {
+			return nil, Abort(err)
+		}
+		return conn, err
+	}).Do(ctx, r)
+}
+
+// Dial is like DialContext with a background context, so the Retrier alone
+// decides when to give up. It keeps the embedded net.Dialer's Dial from
+// bypassing retries.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func isDialRetryable(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialer(t *testing.T) {
+	t.Parallel()
+
+	// Find a free port, then only start listening on it after a few dials.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var attempts int
+	d := &Dialer{
+		Retrier: New(time.Millisecond, time.Millisecond*10),
+		ShouldRetry: func(dialErr error) bool {
+			attempts++
+			if attempts == 3 {
+				l, err = net.Listen("tcp", addr)
+				if err != nil {
+					t.Errorf("listen: %v", err)
+				}
+			}
+			return isDialRetryable(dialErr)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close()
+	l.Close()
+
+	if attempts != 3 {
+		t.Fatalf("got %d failed attempts, want 3", attempts)
+	}
+}
+
+func TestDialer_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	d := &Dialer{
+		Retrier: New(time.Millisecond, time.Millisecond),
+		ShouldRetry: func(err error) bool {
+			attempts++
+			return false
+		},
+	}
+	_, err := d.DialContext(context.Background(), "bogus", "nowhere")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if attempts != 1 {
+		t.Fatalf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestDialer_Dial(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	// Dial goes through the retries too.
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	var attempts int
+	d := &Dialer{
+		Retrier: r,
+		ShouldRetry: func(err error) bool {
+			attempts++
+			return true
+		},
+	}
+	if _, err := d.Dial("tcp", addr); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 3 {
+		t.Fatalf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestIsDialRetryable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	_, err = net.Dial("tcp", addr)
+	if !isDialRetryable(err) {
+		t.Fatalf("connection refused not retryable: %v", err)
+	}
+	if isDialRetryable(errors.New("unknown network")) {
+		t.Fatalf("arbitrary error retryable")
+	}
+}
@@ -0,0 +1,277 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Option configures a Retrier.
+type Option func(*Retrier)
+
+// WithFloor sets the minimum delay between attempts.
+func WithFloor(d time.Duration) Option {
+	return func(r *Retrier) { r.Floor = d }
+}
+
+// WithCeil sets the maximum delay between attempts.
+func WithCeil(d time.Duration) Option {
+	return func(r *Retrier) { r.Ceil = d }
+}
+
+// WithRate sets the rate at which the delay grows.
+func WithRate(rate float64) Option {
+	return func(r *Retrier) { r.Rate = rate }
+}
+
+// WithJitter sets the jitter applied to each delay.
+func WithJitter(jitter float64) Option {
+	return func(r *Retrier) { r.Jitter = jitter }
+}
+
+// WithJitterDistribution sets the distribution jitter is drawn from.
+func WithJitterDistribution(dist Distribution) Option {
+	return func(r *Retrier) { r.JitterDistribution = dist }
+}
+
+// WithMaxAttempts limits the number of attempts.
+func WithMaxAttempts(n int) Option {
+	return func(r *Retrier) { r.MaxAttempts = n }
+}
+
+// WithMaxElapsed limits how long attempts keep being made.
+func WithMaxElapsed(d time.Duration) Option {
+	return func(r *Retrier) { r.MaxElapsed = d }
+}
+
+// WithAttemptInfo makes Do wrap the error it gives up with in an
+// AttemptsError. See Retrier.WrapErrors.
+func WithAttemptInfo() Option {
+	return func(r *Retrier) { r.WrapErrors = true }
+}
+
+// WithJoinedErrors makes Do return the errors of all attempts rather than
+// just the last. See Retrier.JoinErrors.
+func WithJoinedErrors() Option {
+	return func(r *Retrier) { r.JoinErrors = true }
+}
+
+// WithAttemptTimeout limits how long each attempt may take. See
+// Retrier.AttemptTimeout.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(r *Retrier) { r.AttemptTimeout = d }
+}
+
+// WithNotify sets a function to be called for each failed attempt that is
+// going to be retried. See Retrier.Notify.
+func WithNotify(fn func(attempt int, err error, next time.Duration)) Option {
+	return func(r *Retrier) { r.Notify = fn }
+}
+
+// WithObserver adds a function to be called for each failed attempt that is
+// going to be retried. See Retrier.Observers.
+func WithObserver(fn func(attempt int, err error, next time.Duration)) Option {
+	return func(r *Retrier) { r.AddObserver(fn) }
+}
+
+// WithFallback sets a function to be called when Do gives up. See
+// Retrier.Fallback.
+func WithFallback(fn func(ctx context.Context, err error) error) Option {
+	return func(r *Retrier) { r.Fallback = fn }
+}
+
+// NewRetrier creates a retrier configured by opts. Without options, it
+// backs off from 100ms to 10s and has no limits.
+func NewRetrier(opts ...Option) *Retrier {
+	r := New(time.Millisecond*100, time.Second*10)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Do calls fn until it succeeds, ctx is cancelled, or the attempts allowed by
+// opts run out. Without options, it backs off from 100ms to 10s and only
+// stops on success or cancellation.
+//
+// Do returns the last error from fn, or the reason it gave up if fn was never
+// called. If it gave up because it ran out of attempts or time, the error
+// also matches ErrExhausted or ErrDeadlineExceeded with errors.Is. fn can stop
+// the loop early by returning an error wrapped with Abort, or one that reports
+// itself as not Retryable.
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	return NewRetrier(opts...).Do(ctx, fn)
+}
+
+// Do is like the package-level Do, but runs fn under r as configured. r is
+// Reset first and must not be used concurrently; see Clone.
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.Reset()
+	return r.resume(ctx, fn)
+}
+
+// resume is like Do, but carries on from r's current state, such as one
+// restored with SetState.
+func (r *Retrier) resume(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := r.clock().Now()
+	attempts, err := r.run(ctx, fn)
+	if err != nil && r.WrapErrors {
+		err = &AttemptsError{
+			Attempts: attempts,
+			Elapsed:  r.clock().Now().Sub(start),
+			Err:      err,
+		}
+	}
+	if err != nil {
+		r.emit(Event{Kind: EventGaveUp, Err: err})
+	}
+	if err != nil && r.Fallback != nil {
+		return r.Fallback(ctx, err)
+	}
+	return err
+}
+
+// run runs fn under r, returning the number of attempts made.
+func (r *Retrier) run(ctx context.Context, fn func(ctx context.Context) error) (int, error) {
+	var (
+		err  error
+		errs []error // all errors, if r.JoinErrors
+	)
+	for attempt := 1; ; attempt++ {
+		d, werr := r.next()
+		if werr == nil {
+			d, werr = r.after(err, d)
+		}
+		// MaxAttempts or MaxElapsed ran out.
+		limited := werr != nil
+		if werr == nil {
+			if err != nil {
+				if r.Notify != nil {
+					r.Notify(attempt-1, err, d)
+				}
+				for _, o := range r.Observers {
+					o(attempt-1, err, d)
+				}
+				r.emit(Event{Kind: EventSleep, Attempt: attempt, Delay: d})
+			}
+			paused := r.clock().Now()
+			werr = r.pause(ctx, d)
+			if r.Stats != nil && attempt > 1 {
+				r.Stats.addDelay(r.clock().Now().Sub(paused))
+			}
+		}
+		if werr != nil {
+			if err == nil {
+				return attempt - 1, werr
+			}
+			if limited {
+				return attempt - 1, &limitError{limit: werr, last: join(errs, err)}
+			}
+			return attempt - 1, join(errs, err)
+		}
+		r.emit(Event{Kind: EventAttempt, Attempt: attempt})
+		start := r.clock().Now()
+		err = r.call(ctx, fn)
+		if r.Stats != nil {
+			r.Stats.addAttempt(r.clock().Now().Sub(start))
+		}
+		if r.Breaker != nil {
+			r.Breaker.Record(err)
+		}
+		if l, ok := r.Limiter.(interface{ Record(error) }); ok {
+			l.Record(err)
+		}
+		if err == nil {
+			r.emit(Event{Kind: EventSucceeded, Attempt: attempt})
+			if r.Budget != nil {
+				r.Budget.Deposit()
+			}
+			if r.AdaptiveFloor != nil {
+				r.AdaptiveFloor.Observe(r.clock().Now().Sub(start))
+			}
+			return attempt, nil
+		}
+		r.emit(Event{Kind: EventFailed, Attempt: attempt, Err: err})
+		r.remember(err)
+		inner, abort := aborted(err)
+		if r.JoinErrors {
+			if abort {
+				errs = append(errs, inner)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+		if abort {
+			return attempt, join(errs, inner)
+		}
+		if !IsRetryable(err) {
+			return attempt, join(errs, err)
+		}
+	}
+}
+
+// limitError is returned by Do when it runs out of attempts or time after
+// a failure. It matches both the limit, such as ErrExhausted, and the
+// failure with errors.Is and errors.As, but reads as the failure; use
+// WithAttemptInfo for more.
+type limitError struct {
+	limit, last error
+}
+
+func (e *limitError) Error() string {
+	return e.last.Error()
+}
+
+func (e *limitError) Unwrap() []error {
+	return []error{e.limit, e.last}
+}
+
+// join returns errs joined if there are several, or else last.
+func join(errs []error, last error) error {
+	if len(errs) > 1 {
+		return errors.Join(errs...)
+	}
+	return last
+}
+
+// call makes an attempt, under r.AttemptTimeout if set, recovering any
+// panic if r.RecoverPanics is set.
+func (r *Retrier) call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if r.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.AttemptTimeout)
+		defer cancel()
+	}
+	if r.RecoverPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+	}
+	return fn(ctx)
+}
+
+// AttemptsError is returned by Do when Retrier.WrapErrors is set, wrapping
+// the error it gave up with.
+type AttemptsError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Elapsed is how long Do ran for.
+	Elapsed time.Duration
+	Err     error
+}
+
+func (e *AttemptsError) Error() string {
+	noun := "attempts"
+	if e.Attempts == 1 {
+		noun = "attempt"
+	}
+	return fmt.Sprintf("after %d %s over %v: %v", e.Attempts, noun, e.Elapsed, e.Err)
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,280 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var calls int
+	err := Do(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestDo_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fail := errors.New("fail")
+
+	var calls int
+	err := Do(ctx, func(ctx context.Context) error {
+		calls++
+		return fail
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithMaxAttempts(4))
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 4 {
+		t.Fatalf("got %d calls, want 4", calls)
+	}
+	if !errors.Is(err, ErrExhausted) {
+		t.Fatalf("got %v, want it to match %v", err, ErrExhausted)
+	}
+}
+
+func TestDo_MaxElapsed(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	fail := errors.New("fail")
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return fail
+	},
+		WithFloor(time.Second),
+		WithCeil(time.Second),
+		WithMaxElapsed(3*time.Second),
+		func(r *Retrier) { r.Clock = clock },
+	)
+	if !errors.Is(err, fail) || !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("got %v, want it to match %v and %v", err, fail, ErrDeadlineExceeded)
+	}
+	if errors.Is(err, ErrExhausted) {
+		t.Fatalf("got %v, don't want it to match %v", err, ErrExhausted)
+	}
+}
+
+func TestDo_ContextCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Do(ctx, func(ctx context.Context) error {
+		t.Fatalf("called even though context cancelled")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	r := NewRetrier(
+		WithFloor(time.Second),
+		WithCeil(time.Minute),
+		WithRate(3),
+		WithJitter(0.5),
+		WithMaxAttempts(7),
+		WithMaxElapsed(time.Hour),
+		WithNotify(func(int, error, time.Duration) {}),
+	)
+	if r.Floor != time.Second || r.Ceil != time.Minute || r.Rate != 3 || r.Jitter != 0.5 || r.MaxAttempts != 7 || r.MaxElapsed != time.Hour || r.Notify == nil {
+		t.Fatalf("options not applied: %+v", r)
+	}
+
+	r = NewRetrier()
+	if r.Floor != 100*time.Millisecond || r.Ceil != 10*time.Second || r.MaxAttempts != 0 {
+		t.Fatalf("unexpected defaults: %+v", r)
+	}
+}
+
+func TestDo_Notify(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	fail := errors.New("fail")
+
+	type call struct {
+		attempt int
+		err     error
+		next    time.Duration
+	}
+	var calls []call
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return fail
+	},
+		WithFloor(time.Second),
+		WithCeil(time.Minute),
+		WithRate(2),
+		WithMaxAttempts(3),
+		WithNotify(func(attempt int, err error, next time.Duration) {
+			calls = append(calls, call{attempt, err, next})
+		}),
+		func(r *Retrier) { r.Clock = clock },
+	)
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+
+	want := []call{
+		{1, fail, time.Second * 2},
+		{2, fail, time.Second * 4},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("got %d notifications, want %d", len(calls), len(want))
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("notification %d: got %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestDo_Fallback(t *testing.T) {
+	t.Parallel()
+
+	fail := errors.New("fail")
+	var got error
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return fail
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithMaxAttempts(2),
+		WithFallback(func(ctx context.Context, err error) error {
+			got = err
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("got %v, want the fallback's nil", err)
+	}
+	if !errors.Is(got, fail) {
+		t.Fatalf("fallback got %v, want %v", got, fail)
+	}
+}
+
+func TestDo_AttemptInfo(t *testing.T) {
+	clock := newTestClock()
+	fail := errors.New("fail")
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return fail
+	},
+		WithFloor(time.Second),
+		WithCeil(time.Second),
+		WithMaxAttempts(3),
+		WithAttemptInfo(),
+		func(r *Retrier) { r.Clock = clock },
+	)
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want it to wrap %v", err, fail)
+	}
+	var ae *AttemptsError
+	if !errors.As(err, &ae) || ae.Attempts != 3 || ae.Elapsed != 2*time.Second {
+		t.Fatalf("got %#v", err)
+	}
+	if got, want := err.Error(), "after 3 attempts over 2s: fail"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDo_JoinedErrors(t *testing.T) {
+	t.Parallel()
+
+	errs := []error{errors.New("dns"), errors.New("tls"), errors.New("503")}
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errs[calls-1]
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithMaxAttempts(3), WithJoinedErrors())
+	for _, want := range errs {
+		if !errors.Is(err, want) {
+			t.Errorf("got %v, want it to include %v", err, want)
+		}
+	}
+	if got, want := err.Error(), "dns\ntls\n503"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDo_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			// Hang until the attempt times out.
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithAttemptTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+
+	// The outer context still bounds the loop.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = Do(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithAttemptTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() == nil {
+		t.Fatalf("got %v before the outer deadline", err)
+	}
+}
+
+func BenchmarkDo(b *testing.B) {
+	ctx := context.Background()
+	fn := func(ctx context.Context) error { return nil }
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Do(ctx, fn)
+	}
+}
+
+func TestRetrier_Observers(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	tmpl := New(time.Millisecond, time.Millisecond)
+	tmpl.MaxAttempts = 2
+	tmpl.AddObserver(func(int, error, time.Duration) { got = append(got, "tmpl") })
+
+	// Observers added to clones stay with them.
+	a, b := tmpl.Clone(), tmpl.Clone()
+	a.AddObserver(func(int, error, time.Duration) { got = append(got, "a") })
+	b.AddObserver(func(int, error, time.Duration) { got = append(got, "b") })
+	a.Notify = func(int, error, time.Duration) { got = append(got, "notify") }
+
+	fail := errors.New("fail")
+	if err := a.Do(context.Background(), func(ctx context.Context) error { return fail }); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if want := []string{"notify", "tmpl", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if len(tmpl.Observers) != 1 {
+		t.Fatalf("template has %d observers, want 1", len(tmpl.Observers))
+	}
+}
@@ -1,2 +1,10 @@
 // Package retry runs a fallible block of code until it succeeds.
+//
+// Functions and methods that run a whole retry loop with a *Retrier, such
+// as Retrier.Do, FuncCtx.Do, Batch and Hedge, Reset it first and use it for
+// the duration of the call, so one Retrier can serve many operations in
+// turn, but not at once. Helpers that run attempts concurrently or beyond
+// the call, such as Race, Scheduler, Dialer and Reader, work on clones and
+// leave it untouched. Either way, its configuration is shared with clones,
+// as described on Clone.
 package retry
@@ -0,0 +1,71 @@
+package retry
+
+import "time"
+
+// EventKind says what an Event reports.
+type EventKind int
+
+const (
+	// EventAttempt means an attempt is starting.
+	EventAttempt EventKind = iota
+	// EventFailed means an attempt failed.
+	EventFailed
+	// EventSleep means the retrier is backing off before the next attempt.
+	EventSleep
+	// EventSucceeded means an attempt succeeded.
+	EventSucceeded
+	// EventGaveUp means no further attempts will be made after a failure.
+	EventGaveUp
+	// EventHotLoop means attempts are coming faster than HotLoopRate. Wait
+	// sends it too, not just Do.
+	EventHotLoop
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAttempt:
+		return "attempt"
+	case EventFailed:
+		return "failed"
+	case EventSleep:
+		return "sleep"
+	case EventSucceeded:
+		return "succeeded"
+	case EventGaveUp:
+		return "gave up"
+	case EventHotLoop:
+		return "hot loop"
+	}
+	return "unknown"
+}
+
+// Event reports the progress of Do, for rendering it live.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// Attempt is the number of the attempt concerned, starting at 1. For
+	// EventSleep and EventHotLoop, it is the attempt being waited for. It is
+	// zero for EventGaveUp.
+	Attempt int
+	// Delay is the backoff delay, for EventSleep.
+	Delay time.Duration
+	// Err is the error, for EventFailed and EventGaveUp.
+	Err error
+}
+
+// WithEvents sets a channel to receive events. See Retrier.Events.
+func WithEvents(c chan<- Event) Option {
+	return func(r *Retrier) { r.Events = c }
+}
+
+// emit sends e to r.Events, if set, dropping it if the channel is full.
+func (r *Retrier) emit(e Event) {
+	if r.Events == nil {
+		return
+	}
+	e.Time = r.clock().Now()
+	select {
+	case r.Events <- e:
+	default:
+	}
+}
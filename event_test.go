@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan Event, 10)
+	fail := errors.New("fail")
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return fail
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithMaxAttempts(2), WithEvents(events))
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	close(events)
+
+	want := []Event{
+		{Kind: EventAttempt, Attempt: 1},
+		{Kind: EventFailed, Attempt: 1, Err: fail},
+		{Kind: EventSleep, Attempt: 2, Delay: time.Millisecond},
+		{Kind: EventAttempt, Attempt: 2},
+		{Kind: EventFailed, Attempt: 2, Err: fail},
+		{Kind: EventGaveUp, Err: fail},
+	}
+	var got []Event
+	for e := range events {
+		if e.Time.IsZero() {
+			t.Errorf("%v event has no time", e.Kind)
+		}
+		e.Time = time.Time{}
+		got = append(got, e)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+	for i := range want {
+		// The error Do gives up with also says why.
+		if errors.Is(got[i].Err, want[i].Err) {
+			got[i].Err = want[i].Err
+		}
+		if got[i] != want[i] {
+			t.Errorf("event %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEvents_Full(t *testing.T) {
+	t.Parallel()
+
+	// A full channel must not hold up the retry loop.
+	events := make(chan Event)
+	err := Do(context.Background(), func(ctx context.Context) error {
+		return nil
+	}, WithEvents(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
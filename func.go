@@ -0,0 +1,49 @@
+package retry
+
+import "context"
+
+// FuncCtx is a fallible operation that produces a value and observes the
+// context of the retry loop running it.
+type FuncCtx[T any] func(ctx context.Context) (T, error)
+
+// Do calls f until it succeeds or r gives up, passing ctx to every attempt.
+// It returns the value of the successful attempt, or the last error. r is
+// Reset first, so it can be reused for the next call.
+func (f FuncCtx[T]) Do(ctx context.Context, r *Retrier) (T, error) {
+	var v T
+	err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		v, err = f(ctx)
+		return err
+	})
+	return v, err
+}
+
+// DoOr is like Do, but if f fails, it returns what fallback returns for the
+// error instead, such as a cached or default value.
+func (f FuncCtx[T]) DoOr(ctx context.Context, r *Retrier, fallback func(ctx context.Context, err error) (T, error)) (T, error) {
+	v, err := f.Do(ctx, r)
+	if err != nil {
+		return fallback(ctx, err)
+	}
+	return v, nil
+}
+
+// Func2 is like FuncCtx for operations that produce two values.
+type Func2[A, B any] func(ctx context.Context) (A, B, error)
+
+// Do calls f until it succeeds or r gives up, passing ctx to every attempt.
+// It returns the values of the successful attempt, or the last error. r is
+// Reset first.
+func (f Func2[A, B]) Do(ctx context.Context, r *Retrier) (A, B, error) {
+	var (
+		a A
+		b B
+	)
+	err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		a, b, err = f(ctx)
+		return err
+	})
+	return a, b, err
+}
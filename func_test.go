@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestFuncCtx(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "loop")
+	r := New(time.Millisecond, time.Millisecond)
+
+	var calls int
+	v, err := FuncCtx[int](func(ctx context.Context) (int, error) {
+		if ctx.Value(ctxKey{}) != "loop" {
+			t.Errorf("attempt did not receive loop context")
+		}
+		calls++
+		if calls < 3 {
+			return 0, errors.New("not yet")
+		}
+		return 42, nil
+	}).Do(ctx, r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != 42 {
+		t.Fatalf("got %d, want 42", v)
+	}
+}
+
+func TestFuncCtx_Cancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := New(time.Hour, time.Hour)
+
+	fail := errors.New("fail")
+	_, err := FuncCtx[int](func(ctx context.Context) (int, error) {
+		cancel()
+		<-ctx.Done()
+		return 0, fail
+	}).Do(ctx, r)
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+}
+
+func TestFuncCtx_DoOr(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+
+	v, err := FuncCtx[string](func(ctx context.Context) (string, error) {
+		return "", errors.New("fail")
+	}).DoOr(context.Background(), r, func(ctx context.Context, err error) (string, error) {
+		return "cached", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "cached" {
+		t.Fatalf("got %q, want %q", v, "cached")
+	}
+}
+
+func TestFunc2(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+
+	var calls int
+	a, b, err := Func2[string, int](func(ctx context.Context) (string, int, error) {
+		calls++
+		if calls < 2 {
+			return "", 0, errors.New("not yet")
+		}
+		return "addr", 7, nil
+	}).Do(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a != "addr" || b != 7 {
+		t.Fatalf("got (%q, %d), want (%q, %d)", a, b, "addr", 7)
+	}
+}
+
+func BenchmarkFuncCtx(b *testing.B) {
+	r := New(0, 0)
+	ctx := context.Background()
+	f := FuncCtx[int](func(ctx context.Context) (int, error) { return 1, nil })
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset()
+		_, _ = f.Do(ctx, r)
+	}
+}
+
+func TestFuncCtx_Allocs(t *testing.T) {
+	r := New(0, 0)
+	ctx := context.Background()
+	f := FuncCtx[int](func(ctx context.Context) (int, error) { return 1, nil })
+	n := testing.AllocsPerRun(100, func() {
+		r.Reset()
+		_, _ = f.Do(ctx, r)
+	})
+	if n != 0 {
+		t.Fatalf("Do allocates %v times", n)
+	}
+}
+
+func TestFuncCtx_Reuse(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Second)
+	r.MaxAttempts = 2
+	r.Clock = clock
+
+	ctx := context.Background()
+	fail := errors.New("fail")
+	f := FuncCtx[int](func(ctx context.Context) (int, error) { return 0, fail })
+	if _, err := f.Do(ctx, r); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+
+	// The second call gets a fresh set of attempts, and its first attempt
+	// is immediate.
+	var calls int
+	start := clock.Elapsed()
+	_, err := FuncCtx[int](func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			if d := clock.Elapsed() - start; d != 0 {
+				t.Errorf("first attempt delayed by %v", d)
+			}
+		}
+		return 0, fail
+	}).Do(ctx, r)
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
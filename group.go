@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group lets related operations, such as the calls that make up one user
+// request, share an attempt budget and a deadline, so their retries between
+// them can't blow the request's time limit. It is safe for concurrent use.
+type Group struct {
+	// Retrier is cloned for each operation. If nil, operations back off as
+	// they would under the package-level Do.
+	Retrier *Retrier
+
+	// MaxAttempts, if positive, limits the number of attempts made by all
+	// operations together.
+	MaxAttempts int
+
+	// Deadline, if non-zero, is when all operations must be done by.
+	Deadline time.Time
+
+	mu       sync.Mutex
+	attempts int
+}
+
+// NewGroup creates a group whose operations back off according to r, and
+// may make maxAttempts attempts between them within timeout of now. Zero
+// values mean no limit.
+func NewGroup(r *Retrier, maxAttempts int, timeout time.Duration) *Group {
+	g := &Group{Retrier: r, MaxAttempts: maxAttempts}
+	if timeout > 0 {
+		g.Deadline = time.Now().Add(timeout)
+	}
+	return g
+}
+
+// Attempts returns the number of attempts made so far.
+func (g *Group) Attempts() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.attempts
+}
+
+func (g *Group) take() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.MaxAttempts > 0 && g.attempts >= g.MaxAttempts {
+		return false
+	}
+	g.attempts++
+	return true
+}
+
+// Do calls fn like the package-level Do, with a clone of the group's Retrier,
+// until it succeeds or the group's attempts or time run out. It returns the
+// last error from fn, or why it gave up if fn was never called.
+func (g *Group) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !g.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, g.Deadline)
+		defer cancel()
+	}
+
+	r := g.Retrier
+	if r == nil {
+		r = NewRetrier()
+	} else {
+		r = r.Clone()
+	}
+	var last error
+	return r.Do(ctx, func(ctx context.Context) error {
+		if !g.take() {
+			if last == nil {
+				return Abort(ErrExhausted)
+			}
+			return Abort(last)
+		}
+		last = fn(ctx)
+		return last
+	})
+}
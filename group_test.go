@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(New(time.Millisecond, time.Millisecond), 5, 0)
+	ctx := context.Background()
+	fail := errors.New("fail")
+
+	var calls int
+	err := g.Do(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return fail
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second operation only gets what the first left over.
+	calls = 0
+	err = g.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+
+	err = g.Do(ctx, func(ctx context.Context) error {
+		t.Fatal("called after the group ran out of attempts")
+		return nil
+	})
+	if !errors.Is(err, ErrExhausted) {
+		t.Fatalf("got %v, want %v", err, ErrExhausted)
+	}
+}
+
+func TestGroup_Deadline(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(New(time.Millisecond, time.Millisecond), 0, 10*time.Millisecond)
+	err := g.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestGroup_NilRetrier(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(nil, 1, 0)
+	if err := g.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
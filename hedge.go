@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Hedge calls fn, and each time r's next delay passes without a success,
+// makes another concurrent attempt, in case the earlier ones are stuck in a
+// slow tail. It returns the first success and cancels the other attempts.
+//
+// No attempts are made once r gives up, but those in flight are waited for.
+// Hedge returns early with an error that is not Retryable or is wrapped with
+// Abort. Otherwise, if every attempt fails, it returns the last error. Only
+// r's schedule and MaxAttempts apply; Notify, Breaker, Budget and Limiter
+// are not consulted. r is Reset first.
+func Hedge[T any](ctx context.Context, r *Retrier, fn FuncCtx[T]) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+
+	r.Reset()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result)
+	var inflight int
+	launch := func() {
+		inflight++
+		go func() {
+			v, err := fn(ctx)
+			select {
+			case results <- result{v, err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	var (
+		zero    T
+		lastErr error
+		clock   = r.clock()
+	)
+	fail := func(err error) (T, error) {
+		if lastErr != nil {
+			return zero, lastErr
+		}
+		return zero, err
+	}
+	for {
+		d, werr := r.next()
+		var timer <-chan time.Time
+		if werr == nil {
+			timer = clock.After(d)
+		} else if inflight == 0 {
+			return fail(werr)
+		}
+
+	wait:
+		for {
+			select {
+			case <-timer:
+				launch()
+				break wait
+			case res := <-results:
+				inflight--
+				if res.err == nil {
+					return res.v, nil
+				}
+				if inner, ok := aborted(res.err); ok {
+					return zero, inner
+				}
+				if !IsRetryable(res.err) {
+					return zero, res.err
+				}
+				lastErr = res.err
+				if werr != nil && inflight == 0 {
+					return zero, lastErr
+				}
+			case <-ctx.Done():
+				return fail(ctx.Err())
+			}
+		}
+	}
+}
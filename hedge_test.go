@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	r := New(10*time.Millisecond, 10*time.Millisecond)
+	r.Rate = 1
+	v, err := Hedge(context.Background(), r, func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			// The first attempt hangs until the hedge wins.
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return int(n), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 {
+		t.Fatalf("got %d, want the result of the second attempt", v)
+	}
+}
+
+func TestHedge_AllFail(t *testing.T) {
+	t.Parallel()
+
+	fail := errors.New("fail")
+	var calls int32
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	_, err := Hedge(context.Background(), r, func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, fail
+	})
+	if !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestHedge_Abort(t *testing.T) {
+	t.Parallel()
+
+	fail := errors.New("fail")
+	_, err := Hedge(context.Background(), New(time.Hour, time.Hour), func(ctx context.Context) (int, error) {
+		return 0, Abort(fail)
+	})
+	if err != fail {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+}
@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is how many recent latencies a LatencyFloor remembers.
+const latencySamples = 64
+
+// LatencyFloor derives a floor from the observed latency of successful
+// attempts, so delays stay in proportion to how long a dependency normally
+// takes to respond. It is safe for concurrent use, and may be shared between
+// retriers talking to the same dependency.
+type LatencyFloor struct {
+	// Multiple scales the 95th percentile latency into a floor.
+	Multiple float64
+
+	mu      sync.Mutex
+	samples [latencySamples]time.Duration
+	n       int
+}
+
+// NewLatencyFloor creates a LatencyFloor that sets the floor to multiple
+// times the 95th percentile of recent latencies.
+func NewLatencyFloor(multiple float64) *LatencyFloor {
+	return &LatencyFloor{Multiple: multiple}
+}
+
+// Observe records the latency of a successful attempt.
+func (l *LatencyFloor) Observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.samples[l.n%latencySamples] = d
+	l.n++
+}
+
+// Floor returns Multiple times the 95th percentile of recent latencies, or
+// zero if none have been observed.
+func (l *LatencyFloor) Floor() time.Duration {
+	l.mu.Lock()
+	n := l.n
+	if n > latencySamples {
+		n = latencySamples
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, l.samples[:n])
+	l.mu.Unlock()
+
+	if n == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	p95 := samples[(n*95+99)/100-1]
+	return saturate(float64(p95)*l.Multiple, math.MaxInt64)
+}
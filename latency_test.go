@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyFloor(t *testing.T) {
+	l := NewLatencyFloor(2)
+	if f := l.Floor(); f != 0 {
+		t.Fatalf("floor without samples: %v", f)
+	}
+
+	for i := 1; i <= 100; i++ {
+		l.Observe(time.Duration(i) * time.Millisecond)
+	}
+	// The last 64 samples are 37ms..100ms, whose p95 is 97ms.
+	if f, want := l.Floor(), time.Millisecond*97*2; f != want {
+		t.Fatalf("got floor %v, want %v", f, want)
+	}
+}
+
+func TestLatencyFloor_Retrier(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Millisecond, time.Minute)
+	r.Rate = 2
+	r.Clock = clock
+	r.AdaptiveFloor = NewLatencyFloor(3)
+
+	ctx := context.Background()
+	err := r.Do(ctx, func(ctx context.Context) error {
+		clock.Advance(time.Second)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Reset()
+	r.Wait(ctx)
+	r.Wait(ctx)
+	if want := time.Second * 3 * 2; r.Delay != want {
+		t.Fatalf("got delay %v, want %v", r.Delay, want)
+	}
+}
+
+func TestLatencyFloor_Overflow(t *testing.T) {
+	l := NewLatencyFloor(math.MaxFloat64)
+	l.Observe(time.Hour)
+	if got := l.Floor(); got != math.MaxInt64 {
+		t.Fatalf("got %v, want the largest Duration", got)
+	}
+}
@@ -0,0 +1,19 @@
+package retry
+
+import "context"
+
+// Limiter paces attempts. *rate.Limiter from golang.org/x/time/rate
+// satisfies it.
+type Limiter interface {
+	// Wait blocks until an attempt may be made, returning an error if ctx
+	// is done first or the attempt can never be allowed.
+	Wait(ctx context.Context) error
+}
+
+// LimiterFunc adapts a function to a Limiter.
+type LimiterFunc func(ctx context.Context) error
+
+// Wait calls f(ctx).
+func (f LimiterFunc) Wait(ctx context.Context) error {
+	return f(ctx)
+}
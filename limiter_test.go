@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	var waits int
+	limit := errors.New("limited")
+	r := New(time.Millisecond, time.Millisecond)
+	r.Limiter = LimiterFunc(func(ctx context.Context) error {
+		waits++
+		if waits > 2 {
+			return limit
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	// The first attempt is gated too.
+	if err := r.WaitErr(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitErr(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitErr(ctx); !errors.Is(err, limit) {
+		t.Fatalf("got %v, want %v", err, limit)
+	}
+	if waits != 3 {
+		t.Fatalf("got %d waits, want 3", waits)
+	}
+}
+
+func TestLimiter_Do(t *testing.T) {
+	t.Parallel()
+
+	var waits, calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	},
+		WithFloor(time.Millisecond),
+		WithCeil(time.Millisecond),
+		func(r *Retrier) {
+			r.Limiter = LimiterFunc(func(ctx context.Context) error {
+				waits++
+				return nil
+			})
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waits != calls {
+		t.Fatalf("got %d waits for %d calls", waits, calls)
+	}
+}
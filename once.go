@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+)
+
+// OnceStore records which operations Once has completed, apart from any
+// retrier states a Store keeps under the same keys. Implementations must be
+// safe for concurrent use.
+type OnceStore interface {
+	// Done reports whether key was marked done.
+	Done(ctx context.Context, key string) (bool, error)
+	// MarkDone records that key is done.
+	MarkDone(ctx context.Context, key string) error
+	// Forget removes the record for key, if any.
+	Forget(ctx context.Context, key string) error
+}
+
+// Once runs fn unless s records that it already succeeded under key, and
+// records it once it does. It guards side-effecting steps, such as sending
+// an email, inside an operation that an outer loop retries as a whole, so
+// the steps that completed aren't repeated when it runs again.
+//
+// If fn succeeds but the record can't be stored, Once returns the store's
+// error. Should the process stop between the two, fn runs again next time.
+func Once(ctx context.Context, s OnceStore, key string, fn func(ctx context.Context) error) error {
+	done, err := s.Done(ctx, key)
+	if err != nil {
+		return fmt.Errorf("retry: once %q: %w", key, err)
+	}
+	if done {
+		return nil
+	}
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if err := s.MarkDone(ctx, key); err != nil {
+		return fmt.Errorf("retry: once %q: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOnce(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	var sends, calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		if err := Once(ctx, store, "send", func(ctx context.Context) error {
+			sends++
+			return nil
+		}); err != nil {
+			return err
+		}
+		calls++
+		if calls < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sends != 1 {
+		t.Fatalf("sent %d times, want 1", sends)
+	}
+}
+
+func TestOnce_Failed(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+	fail := errors.New("fail")
+	if err := Once(ctx, store, "step", func(ctx context.Context) error { return fail }); err != fail {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	// A failed run isn't recorded, so the next one goes ahead.
+	var ran bool
+	if err := Once(ctx, store, "step", func(ctx context.Context) error {
+		ran = true
+		return nil
+	}); err != nil || !ran {
+		t.Fatalf("got %v, ran %v", err, ran)
+	}
+}
@@ -0,0 +1,28 @@
+package retry
+
+import "fmt"
+
+// PanicError is the error an attempt that panicked fails with when
+// Retrier.RecoverPanics is set.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("retry: attempt panicked: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithRecover makes Do recover panics in attempts. See
+// Retrier.RecoverPanics.
+func WithRecover() Option {
+	return func(r *Retrier) { r.RecoverPanics = true }
+}
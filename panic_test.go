@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return nil
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithRecover())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
+
+func TestRecoverPanics_Error(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("cause")
+	err := Do(context.Background(), func(ctx context.Context) error {
+		panic(cause)
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond), WithMaxAttempts(2), WithRecover())
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v, want a PanicError", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatalf("got %v, want it to wrap %v", err, cause)
+	}
+	if !strings.Contains(string(pe.Stack), "TestRecoverPanics_Error") {
+		t.Fatalf("stack doesn't include the panicking function:\n%s", pe.Stack)
+	}
+}
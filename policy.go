@@ -0,0 +1,231 @@
+package retry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is the configuration of a Retrier in a form that can be loaded from
+// a config file. In JSON, durations are strings such as "1.5s":
+//
+//	{"floor": "100ms", "ceil": "10s", "rate": 2, "max_attempts": 5}
+//
+// As text, it is a comma-separated list of the same keys and values:
+//
+//	floor=100ms,ceil=10s,rate=2,max_attempts=5
+type Policy struct {
+	Floor, Ceil time.Duration
+	// Rate defaults to math.Phi, as in New.
+	Rate        float64
+	Jitter      float64
+	MaxAttempts int
+	MaxElapsed  time.Duration
+}
+
+// Retrier returns a new retrier configured by p.
+func (p Policy) Retrier() *Retrier {
+	r := New(p.Floor, p.Ceil)
+	if p.Rate != 0 {
+		r.Rate = p.Rate
+	}
+	r.Jitter = p.Jitter
+	r.MaxAttempts = p.MaxAttempts
+	r.MaxElapsed = p.MaxElapsed
+	return r
+}
+
+// policyJSON is the JSON form of Policy.
+type policyJSON struct {
+	Floor       string  `json:"floor,omitempty"`
+	Ceil        string  `json:"ceil,omitempty"`
+	Rate        float64 `json:"rate,omitempty"`
+	Jitter      float64 `json:"jitter,omitempty"`
+	MaxAttempts int     `json:"max_attempts,omitempty"`
+	MaxElapsed  string  `json:"max_elapsed,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Policy) MarshalJSON() ([]byte, error) {
+	j := policyJSON{
+		Rate:        p.Rate,
+		Jitter:      p.Jitter,
+		MaxAttempts: p.MaxAttempts,
+	}
+	for _, f := range []struct {
+		s *string
+		d time.Duration
+	}{{&j.Floor, p.Floor}, {&j.Ceil, p.Ceil}, {&j.MaxElapsed, p.MaxElapsed}} {
+		if f.d != 0 {
+			*f.s = f.d.String()
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Policy) UnmarshalJSON(b []byte) error {
+	var j policyJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	q := Policy{
+		Rate:        j.Rate,
+		Jitter:      j.Jitter,
+		MaxAttempts: j.MaxAttempts,
+	}
+	for _, f := range []struct {
+		name string
+		s    string
+		d    *time.Duration
+	}{{"floor", j.Floor, &q.Floor}, {"ceil", j.Ceil, &q.Ceil}, {"max_elapsed", j.MaxElapsed, &q.MaxElapsed}} {
+		if f.s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.s)
+		if err != nil {
+			return fmt.Errorf("retry: policy %s: %w", f.name, err)
+		}
+		*f.d = d
+	}
+	*p = q
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Policy) MarshalText() ([]byte, error) {
+	var fields []string
+	add := func(key, value string) {
+		fields = append(fields, key+"="+value)
+	}
+	if p.Floor != 0 {
+		add("floor", p.Floor.String())
+	}
+	if p.Ceil != 0 {
+		add("ceil", p.Ceil.String())
+	}
+	if p.Rate != 0 {
+		add("rate", strconv.FormatFloat(p.Rate, 'g', -1, 64))
+	}
+	if p.Jitter != 0 {
+		add("jitter", strconv.FormatFloat(p.Jitter, 'g', -1, 64))
+	}
+	if p.MaxAttempts != 0 {
+		add("max_attempts", strconv.Itoa(p.MaxAttempts))
+	}
+	if p.MaxElapsed != 0 {
+		add("max_elapsed", p.MaxElapsed.String())
+	}
+	return []byte(strings.Join(fields, ",")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Policy) UnmarshalText(b []byte) error {
+	var q Policy
+	for _, field := range strings.Split(string(b), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return fmt.Errorf("retry: policy field %q is not key=value", field)
+		}
+		if err := q.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	*p = q
+	return nil
+}
+
+// PolicyChange is a field that differs between two policies.
+type PolicyChange struct {
+	// Key is the field's name in text and JSON, such as "max_attempts".
+	Key string
+	// Old and New are the field's values, formatted as in text.
+	Old, New string
+}
+
+// Diff returns the fields in which next differs from p, in the order
+// MarshalText writes them. It lets config reloads log how retry behavior
+// changed.
+func (p Policy) Diff(next Policy) []PolicyChange {
+	var changes []PolicyChange
+	for _, key := range policyKeys {
+		if before, after := p.get(key), next.get(key); before != after {
+			changes = append(changes, PolicyChange{Key: key, Old: before, New: after})
+		}
+	}
+	return changes
+}
+
+// policyKeys are the names of Policy's fields in text and JSON.
+var policyKeys = []string{"floor", "ceil", "rate", "jitter", "max_attempts", "max_elapsed"}
+
+// FromEnv returns a retrier configured by environment variables named after
+// the policy keys, upper-cased and prefixed with prefix and "_RETRY_". For
+// example, FromEnv("FOO") reads FOO_RETRY_FLOOR and FOO_RETRY_MAX_ATTEMPTS.
+// Unset variables keep the defaults of NewRetrier.
+func FromEnv(prefix string) (*Retrier, error) {
+	d := NewRetrier()
+	p := Policy{Floor: d.Floor, Ceil: d.Ceil}
+	for _, key := range policyKeys {
+		name := prefix + "_RETRY_" + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := p.set(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return p.Retrier(), nil
+}
+
+// get formats the field named key.
+func (p Policy) get(key string) string {
+	switch key {
+	case "floor":
+		return p.Floor.String()
+	case "ceil":
+		return p.Ceil.String()
+	case "rate":
+		return strconv.FormatFloat(p.Rate, 'g', -1, 64)
+	case "jitter":
+		return strconv.FormatFloat(p.Jitter, 'g', -1, 64)
+	case "max_attempts":
+		return strconv.Itoa(p.MaxAttempts)
+	case "max_elapsed":
+		return p.MaxElapsed.String()
+	}
+	return ""
+}
+
+// set parses value into the field named key.
+func (p *Policy) set(key, value string) error {
+	var err error
+	switch key {
+	case "floor":
+		p.Floor, err = time.ParseDuration(value)
+	case "ceil":
+		p.Ceil, err = time.ParseDuration(value)
+	case "rate":
+		p.Rate, err = strconv.ParseFloat(value, 64)
+	case "jitter":
+		p.Jitter, err = strconv.ParseFloat(value, 64)
+	case "max_attempts":
+		p.MaxAttempts, err = strconv.Atoi(value)
+	case "max_elapsed":
+		p.MaxElapsed, err = time.ParseDuration(value)
+	default:
+		return fmt.Errorf("retry: unknown policy field %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("retry: policy %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicy_JSON(t *testing.T) {
+	var p Policy
+	err := json.Unmarshal([]byte(`{"floor": "100ms", "ceil": "10s", "jitter": 0.1, "max_attempts": 5, "max_elapsed": "1m"}`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Policy{
+		Floor:       100 * time.Millisecond,
+		Ceil:        10 * time.Second,
+		Jitter:      0.1,
+		MaxAttempts: 5,
+		MaxElapsed:  time.Minute,
+	}
+	if p != want {
+		t.Fatalf("got %+v, want %+v", p, want)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Policy
+	if err := json.Unmarshal(b, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again != p {
+		t.Fatalf("round trip through %s gave %+v", b, again)
+	}
+
+	if err := json.Unmarshal([]byte(`{"floor": "soon"}`), &p); err == nil {
+		t.Fatal("accepted a bad duration")
+	}
+}
+
+func TestPolicy_Text(t *testing.T) {
+	var p Policy
+	if err := p.UnmarshalText([]byte("floor=1s, ceil=1m,rate=2,max_attempts=3")); err != nil {
+		t.Fatal(err)
+	}
+	want := Policy{Floor: time.Second, Ceil: time.Minute, Rate: 2, MaxAttempts: 3}
+	if p != want {
+		t.Fatalf("got %+v, want %+v", p, want)
+	}
+
+	b, _ := p.MarshalText()
+	if got := string(b); got != "floor=1s,ceil=1m0s,rate=2,max_attempts=3" {
+		t.Fatalf("got %q", got)
+	}
+
+	for _, bad := range []string{"floor", "floor=x", "speed=1"} {
+		if err := p.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestPolicy_Retrier(t *testing.T) {
+	r := Policy{Floor: time.Second, Ceil: time.Minute, MaxElapsed: time.Hour}.Retrier()
+	if r.Floor != time.Second || r.Ceil != time.Minute || r.MaxElapsed != time.Hour {
+		t.Fatalf("got %+v", r)
+	}
+	if r.Rate != math.Phi {
+		t.Fatalf("got rate %v, want the default", r.Rate)
+	}
+}
+
+func TestPolicy_Diff(t *testing.T) {
+	old := Policy{Floor: time.Second, Ceil: time.Minute, MaxAttempts: 3}
+	next := Policy{Floor: time.Second, Ceil: time.Minute * 2, Jitter: 0.1}
+	want := []PolicyChange{
+		{Key: "ceil", Old: "1m0s", New: "2m0s"},
+		{Key: "jitter", Old: "0", New: "0.1"},
+		{Key: "max_attempts", Old: "3", New: "0"},
+	}
+	if got := old.Diff(next); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got := old.Diff(old); got != nil {
+		t.Fatalf("got %+v for identical policies", got)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("FOO_RETRY_FLOOR", "1s")
+	t.Setenv("FOO_RETRY_MAX_ATTEMPTS", "5")
+
+	r, err := FromEnv("FOO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Floor != time.Second || r.MaxAttempts != 5 {
+		t.Fatalf("got floor %v and max attempts %d", r.Floor, r.MaxAttempts)
+	}
+	if r.Ceil != 10*time.Second || r.Rate != math.Phi {
+		t.Fatalf("got ceil %v and rate %v, want the defaults", r.Ceil, r.Rate)
+	}
+
+	t.Setenv("FOO_RETRY_JITTER", "lots")
+	if _, err := FromEnv("FOO"); err == nil {
+		t.Fatal("accepted a bad value")
+	}
+}
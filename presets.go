@@ -0,0 +1,26 @@
+package retry
+
+import "time"
+
+// PresetFast returns a retrier for quick, local operations, such as talking
+// to a sidecar: it backs off from 10ms to 1s and gives up after 5s.
+func PresetFast() *Retrier {
+	r := New(10*time.Millisecond, time.Second)
+	r.MaxElapsed = 5 * time.Second
+	return r
+}
+
+// PresetNetwork returns a retrier for requests to other services: it backs
+// off from 100ms to 30s and gives up after 2m.
+func PresetNetwork() *Retrier {
+	r := New(100*time.Millisecond, 30*time.Second)
+	r.MaxElapsed = 2 * time.Minute
+	return r
+}
+
+// PresetLongHaul returns a retrier for background work that should keep
+// going through long outages: it backs off from 1s to 5m and never gives
+// up on its own.
+func PresetLongHaul() *Retrier {
+	return New(time.Second, 5*time.Minute)
+}
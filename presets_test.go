@@ -0,0 +1,41 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPresets(t *testing.T) {
+	for name, tc := range map[string]struct {
+		r           *Retrier
+		floor, ceil time.Duration
+	}{
+		"Fast":     {PresetFast(), 10 * time.Millisecond, time.Second},
+		"Network":  {PresetNetwork(), 100 * time.Millisecond, 30 * time.Second},
+		"LongHaul": {PresetLongHaul(), time.Second, 5 * time.Minute},
+	} {
+		if tc.r.Floor != tc.floor || tc.r.Ceil != tc.ceil {
+			t.Errorf("%s: got %v to %v, want %v to %v", name, tc.r.Floor, tc.r.Ceil, tc.floor, tc.ceil)
+		}
+	}
+}
+
+func TestPresetFast_GivesUp(t *testing.T) {
+	clock := newTestClock()
+	r := PresetFast()
+	r.Clock = clock
+
+	ctx := context.Background()
+	var err error
+	for err == nil {
+		err = r.WaitErr(ctx)
+	}
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrDeadlineExceeded)
+	}
+	if got := clock.Elapsed(); got > 5*time.Second {
+		t.Fatalf("kept going for %v", got)
+	}
+}
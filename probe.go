@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"context"
+)
+
+// Probe runs check until ctx is cancelled, calling onChange whenever the
+// outcome flips between healthy (check returned nil) and unhealthy. onChange
+// is also called with the outcome of the first check.
+//
+// While unhealthy, checks back off according to r. While healthy, checks run
+// at a steady interval of r.Ceil, and r is reset so the next outage backs off
+// from the start. r is also Reset before the first check.
+//
+// Probe returns once ctx is cancelled or r allows no further attempts, with
+// the reason.
+func Probe(ctx context.Context, check func(ctx context.Context) error, r *Retrier, onChange func(healthy bool)) error {
+	r.Reset()
+	var healthy, known bool
+	for {
+		err := check(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !known || healthy != (err == nil) {
+			known = true
+			healthy = err == nil
+			if onChange != nil {
+				onChange(healthy)
+			}
+		}
+
+		if healthy {
+			r.Reset()
+			if !r.sleep(ctx, r.Ceil) {
+				return ctx.Err()
+			}
+			continue
+		}
+		if err := r.WaitErr(ctx); err != nil {
+			return err
+		}
+	}
+}
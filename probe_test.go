@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// healthy, unhealthy x3, healthy x2, then stop.
+	outcomes := []bool{true, false, false, false, true, true}
+	var (
+		calls   int
+		changes []bool
+	)
+	check := func(ctx context.Context) error {
+		ok := outcomes[calls]
+		calls++
+		if calls == len(outcomes) {
+			cancel()
+		}
+		if !ok {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+
+	clock := newTestClock()
+	r := New(time.Second, time.Minute)
+	r.Rate = 2
+	r.Clock = clock
+
+	err := Probe(ctx, check, r, func(healthy bool) {
+		changes = append(changes, healthy)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []bool{true, false, true}; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got changes %v, want %v", changes, want)
+	}
+	// Healthy: 1m. Unhealthy: 0, 2s, 4s. Healthy: 1m.
+	if got, want := clock.Elapsed(), time.Minute*2+time.Second*6; got != want {
+		t.Fatalf("probed over %v, want %v", got, want)
+	}
+}
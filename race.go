@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoAlternatives is returned by Race when given no functions to run.
+var ErrNoAlternatives = errors.New("retry: no alternatives to race")
+
+// Race retries each of fns concurrently, each with its own clone of r, and
+// returns the first success, cancelling the others. It suits alternative
+// ways of getting the same thing, such as asking two replicas. If all of
+// them fail, it returns their last errors joined together.
+func Race[T any](ctx context.Context, r *Retrier, fns ...FuncCtx[T]) (T, error) {
+	if len(fns) == 0 {
+		var zero T
+		return zero, ErrNoAlternatives
+	}
+
+	type result struct {
+		v   T
+		err error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, len(fns))
+	for _, fn := range fns {
+		fn := fn
+		r := r.Clone()
+		go func() {
+			v, err := fn.Do(ctx, r)
+			results <- result{v, err}
+		}()
+	}
+
+	errs := make([]error, 0, len(fns))
+	for range fns {
+		res := <-results
+		if res.err == nil {
+			return res.v, nil
+		}
+		errs = append(errs, res.err)
+	}
+	var zero T
+	return zero, errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRace(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	v, err := Race(context.Background(), r,
+		func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+		func(ctx context.Context) (string, error) {
+			return "b", nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "b" {
+		t.Fatalf("got %q, want %q", v, "b")
+	}
+}
+
+func TestRace_AllFail(t *testing.T) {
+	t.Parallel()
+
+	errA, errB := errors.New("a"), errors.New("b")
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+
+	var callsA int
+	_, err := Race(context.Background(), r,
+		func(ctx context.Context) (int, error) {
+			callsA++
+			return 0, errA
+		},
+		func(ctx context.Context) (int, error) {
+			return 0, errB
+		},
+	)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("got %v, want both errors", err)
+	}
+	// Each alternative gets its own attempts.
+	if callsA != 2 {
+		t.Fatalf("got %d calls, want 2", callsA)
+	}
+}
+
+func TestRace_None(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Race[int](context.Background(), New(time.Millisecond, time.Millisecond)); !errors.Is(err, ErrNoAlternatives) {
+		t.Fatalf("got %v, want %v", err, ErrNoAlternatives)
+	}
+}
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Reader is an io.ReadCloser that survives transient read errors by
+// re-opening its source at the offset it had read up to.
+type Reader struct {
+	ctx    context.Context
+	openAt func(ctx context.Context, offset int64) (io.ReadCloser, error)
+	r      *Retrier
+
+	rc     io.ReadCloser
+	offset int64
+}
+
+var _ io.ReadCloser = (*Reader)(nil)
+
+// NewReader creates a Reader over the source opened by openAt. The source is
+// opened at offset 0 on the first Read. Whenever opening or reading fails
+// with an error other than io.EOF, it is re-opened at the offset of the first
+// unread byte, backing off according to a clone of r. Errors that are not
+// Retryable and errors wrapped with Abort end the read.
+//
+// ctx bounds the whole read, including re-opens.
+func NewReader(ctx context.Context, openAt func(ctx context.Context, offset int64) (io.ReadCloser, error), r *Retrier) *Reader {
+	return &Reader{
+		ctx:    ctx,
+		openAt: openAt,
+		r:      r.Clone(),
+	}
+}
+
+// Offset returns the number of bytes read so far.
+func (rd *Reader) Offset() int64 {
+	return rd.offset
+}
+
+// Read implements io.Reader.
+func (rd *Reader) Read(p []byte) (int, error) {
+	for {
+		if rd.rc == nil {
+			// Carry on from rd.r's state rather than Reset it, so a source
+			// that opens but then fails straight away still backs off.
+			var rc io.ReadCloser
+			err := rd.r.resume(rd.ctx, func(ctx context.Context) error {
+				var err error
+				rc, err = rd.openAt(ctx, rd.offset)
+				return err
+			})
+			if err != nil {
+				return 0, err
+			}
+			rd.rc = rc
+		}
+
+		n, err := rd.rc.Read(p)
+		rd.offset += int64(n)
+		if n > 0 {
+			// Data is flowing, so the next outage starts backing off
+			// afresh.
+			rd.r.Reset()
+		}
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		if inner, ok := aborted(err); ok {
+			return n, inner
+		}
+		if !IsRetryable(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return n, err
+		}
+
+		_ = rd.rc.Close()
+		rd.rc = nil
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close closes the current source, if open.
+func (rd *Reader) Close() error {
+	if rd.rc == nil {
+		return nil
+	}
+	err := rd.rc.Close()
+	rd.rc = nil
+	return err
+}
@@ -0,0 +1,121 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyReader fails after limit bytes.
+type flakyReader struct {
+	r     io.Reader
+	limit int
+	err   error
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if f.limit == 0 {
+		return 0, f.err
+	}
+	if len(p) > f.limit {
+		p = p[:f.limit]
+	}
+	n, err := f.r.Read(p)
+	f.limit -= n
+	return n, err
+}
+
+func (f *flakyReader) Close() error { return nil }
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	const data = "the quick brown fox jumps over the lazy dog"
+	var offsets []int64
+	openAt := func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+		offsets = append(offsets, offset)
+		if len(offsets) == 2 {
+			return nil, errors.New("unreachable")
+		}
+		return &flakyReader{
+			r:     strings.NewReader(data[offset:]),
+			limit: 10,
+			err:   errors.New("connection reset"),
+		}, nil
+	}
+
+	rd := NewReader(context.Background(), openAt, New(time.Millisecond, time.Millisecond))
+	defer rd.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, rd); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != data {
+		t.Fatalf("got %q, want %q", buf.String(), data)
+	}
+	if rd.Offset() != int64(len(data)) {
+		t.Fatalf("got offset %d, want %d", rd.Offset(), len(data))
+	}
+
+	want := []int64{0, 10, 10, 20, 30, 40}
+	if len(offsets) != len(want) {
+		t.Fatalf("got offsets %v, want %v", offsets, want)
+	}
+	for i := range want {
+		if offsets[i] != want[i] {
+			t.Fatalf("got offsets %v, want %v", offsets, want)
+		}
+	}
+}
+
+func TestReader_Abort(t *testing.T) {
+	t.Parallel()
+
+	fatal := errors.New("gone")
+	var opens int
+	openAt := func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+		opens++
+		return &flakyReader{r: strings.NewReader("abc"), limit: 1, err: Abort(fatal)}, nil
+	}
+
+	rd := NewReader(context.Background(), openAt, New(time.Millisecond, time.Millisecond))
+	_, err := io.ReadAll(rd)
+	if err != fatal {
+		t.Fatalf("got %v, want %v", err, fatal)
+	}
+	if opens != 1 {
+		t.Fatalf("got %d opens, want 1", opens)
+	}
+}
+
+func TestReader_OpensThenFails(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	var opens int
+	openAt := func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+		opens++
+		return &flakyReader{r: strings.NewReader("abc"), err: errors.New("connection reset")}, nil
+	}
+
+	r := New(time.Second, time.Minute)
+	r.Rate = 2
+	r.MaxAttempts = 4
+	r.Clock = clock
+	rd := NewReader(context.Background(), openAt, r)
+	if _, err := io.ReadAll(rd); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("got %v, want %v", err, ErrExhausted)
+	}
+	// The backoff keeps growing across re-opens that read nothing.
+	if opens != 4 {
+		t.Fatalf("got %d opens, want 4", opens)
+	}
+	if got, want := clock.Elapsed(), 14*time.Second; got != want {
+		t.Fatalf("backed off for %v, want %v", got, want)
+	}
+}
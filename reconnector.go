@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Reconnector keeps a long-lived connection, such as a WebSocket, up by
+// reconnecting whenever the loop using it fails.
+type Reconnector[C any] struct {
+	// Connect establishes a connection.
+	Connect func(ctx context.Context) (C, error)
+
+	// Loop uses a connection until it fails, returning the error, or until
+	// the work is done, returning nil. Loop is responsible for closing the
+	// connection.
+	Loop func(ctx context.Context, conn C) error
+
+	// Retrier governs the backoff between connection attempts. It is
+	// cloned by Run. If nil, attempts back off from 100ms to 5s.
+	Retrier *Retrier
+
+	// HealthyAfter is how long a connection must last for the backoff to
+	// start afresh once it fails. Zero means the backoff is never reset.
+	HealthyAfter time.Duration
+}
+
+// Run connects and runs Loop until it returns nil, ctx is cancelled, Retrier
+// gives up, or Connect or Loop return an error that is not Retryable or is
+// wrapped with Abort. It returns the last error from Connect or Loop, or the
+// reason it gave up if there was none.
+func (rc *Reconnector[C]) Run(ctx context.Context) error {
+	r := rc.Retrier
+	if r == nil {
+		r = New(time.Millisecond*100, time.Second*5)
+	} else {
+		r = r.Clone()
+	}
+	clock := r.clock()
+
+	var err error
+	for {
+		if werr := r.WaitErr(ctx); werr != nil {
+			if err == nil {
+				return werr
+			}
+			return err
+		}
+
+		var conn C
+		conn, err = rc.Connect(ctx)
+		if err == nil {
+			start := clock.Now()
+			err = rc.Loop(ctx, conn)
+			if err == nil {
+				return nil
+			}
+			if rc.HealthyAfter > 0 && clock.Now().Sub(start) >= rc.HealthyAfter {
+				r.Reset()
+			}
+		}
+
+		if inner, ok := aborted(err); ok {
+			return inner
+		}
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+}
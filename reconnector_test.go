@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReconnector(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	r := New(time.Second, time.Minute)
+	r.Rate = 2
+	r.Clock = clock
+
+	var (
+		connects int
+		loops    int
+	)
+	rc := &Reconnector[int]{
+		Connect: func(ctx context.Context) (int, error) {
+			connects++
+			if connects == 2 {
+				return 0, errors.New("refused")
+			}
+			return connects, nil
+		},
+		Loop: func(ctx context.Context, conn int) error {
+			loops++
+			switch loops {
+			case 1:
+				return errors.New("dropped")
+			case 2:
+				// Stays up long enough to count as healthy.
+				clock.Advance(time.Hour)
+				return errors.New("dropped")
+			}
+			return nil
+		},
+		Retrier:      r,
+		HealthyAfter: time.Minute,
+	}
+
+	if err := rc.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if connects != 4 || loops != 3 {
+		t.Fatalf("got %d connects and %d loops, want 4 and 3", connects, loops)
+	}
+	// 0 for the first connect, 2s and 4s for the failed connect and
+	// loop, then 0 once the backoff was reset by the healthy connection.
+	if got, want := clock.Elapsed(), time.Hour+time.Second*6; got != want {
+		t.Fatalf("backed off for %v, want %v", got-time.Hour, want-time.Hour)
+	}
+}
+
+func TestReconnector_Abort(t *testing.T) {
+	t.Parallel()
+
+	fatal := errors.New("unauthorized")
+	var connects int
+	rc := &Reconnector[int]{
+		Connect: func(ctx context.Context) (int, error) {
+			connects++
+			return 0, Abort(fatal)
+		},
+		Loop:    func(ctx context.Context, conn int) error { return nil },
+		Retrier: New(time.Millisecond, time.Millisecond),
+	}
+	if err := rc.Run(context.Background()); err != fatal {
+		t.Fatalf("got %v, want %v", err, fatal)
+	}
+	if connects != 1 {
+		t.Fatalf("got %d connects, want 1", connects)
+	}
+}
+
+func TestReconnector_NilRetrier(t *testing.T) {
+	t.Parallel()
+
+	rc := &Reconnector[int]{
+		Connect: func(ctx context.Context) (int, error) { return 0, nil },
+		Loop:    func(ctx context.Context, conn int) error { return nil },
+	}
+	if err := rc.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Refresher caches a value that expires, such as an access token, and
+// refreshes it ahead of expiry.
+//
+// Once the cached value is within the refresh window of expiring, Get starts
+// a refresh in the background and keeps serving the cached value while the
+// refresh retries. Get only fails if the value actually expires before a
+// refresh succeeds.
+type Refresher[T any] struct {
+	fetch  func(ctx context.Context) (T, time.Time, error)
+	r      *Retrier
+	window time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	value  T
+	expiry time.Time
+	err    error
+	// refresh is closed when the in-flight refresh finishes, or nil if
+	// there is none.
+	refresh chan struct{}
+}
+
+// NewRefresher creates a Refresher that obtains values and their expiry from
+// fetch, retrying according to a clone of r, starting window before the
+// current value expires.
+func NewRefresher[T any](fetch func(ctx context.Context) (T, time.Time, error), r *Retrier, window time.Duration) *Refresher[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Refresher[T]{
+		fetch:  fetch,
+		r:      r,
+		window: window,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Get returns the cached value, fetching one first if there is no valid value.
+func (f *Refresher[T]) Get(ctx context.Context) (T, error) {
+	f.mu.Lock()
+	now := f.r.clock().Now()
+	if now.Before(f.expiry) {
+		if !now.Before(f.expiry.Add(-f.window)) {
+			f.startRefresh()
+		}
+		v := f.value
+		f.mu.Unlock()
+		return v, nil
+	}
+	done := f.startRefresh()
+	f.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		var zero T
+		return zero, f.err
+	}
+	return f.value, nil
+}
+
+// Close stops any refresh in progress. Get must not be called afterwards.
+func (f *Refresher[T]) Close() {
+	f.cancel()
+}
+
+// startRefresh starts a refresh unless one is in flight and returns a
+// channel that is closed when it finishes. f.mu must be held.
+func (f *Refresher[T]) startRefresh() <-chan struct{} {
+	if f.refresh != nil {
+		return f.refresh
+	}
+	done := make(chan struct{})
+	f.refresh = done
+	// Only a value that is still valid gives the refresh a reason to stop
+	// early; once it has expired, the refresh gets all its attempts.
+	expiry := f.expiry
+	if !f.r.clock().Now().Before(expiry) {
+		expiry = time.Time{}
+	}
+	go f.run(done, expiry)
+	return done
+}
+
+// run fetches a new value, retrying until it succeeds, the retrier gives up,
+// or the current value, if any, expires.
+func (f *Refresher[T]) run(done chan struct{}, expiry time.Time) {
+	r := f.r.Clone()
+
+	var (
+		value T
+		exp   time.Time
+		err   error
+	)
+	for {
+		if werr := r.WaitErr(f.ctx); werr != nil {
+			if err == nil {
+				err = werr
+			}
+			break
+		}
+		if value, exp, err = f.fetch(f.ctx); err == nil {
+			break
+		}
+		if !expiry.IsZero() && !r.clock().Now().Before(expiry) {
+			break
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		f.value, f.expiry = value, exp
+	}
+	f.err = err
+	f.refresh = nil
+	close(done)
+}
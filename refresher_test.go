@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefresher(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	r := New(time.Second, time.Second)
+	r.MaxAttempts = 1000
+	r.Clock = clock
+
+	var (
+		mu    sync.Mutex
+		calls int
+		fails int
+		token = "a"
+	)
+	fetch := func(ctx context.Context) (string, time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if fails > 0 {
+			fails--
+			return "", time.Time{}, errors.New("unavailable")
+		}
+		return token, clock.Now().Add(time.Hour), nil
+	}
+	f := NewRefresher(fetch, r, time.Minute*10)
+	defer f.Close()
+
+	ctx := context.Background()
+	get := func(want string) {
+		t.Helper()
+		got, err := f.Get(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	waitRefresh := func() {
+		t.Helper()
+		f.mu.Lock()
+		done := f.refresh
+		f.mu.Unlock()
+		if done == nil {
+			t.Fatalf("no refresh in flight")
+		}
+		<-done
+	}
+
+	get("a")
+	get("a")
+	if calls != 1 {
+		t.Fatalf("got %d fetches, want 1", calls)
+	}
+
+	// Within the refresh window, the old token is served while the refresh
+	// retries in the background.
+	mu.Lock()
+	clock.Advance(time.Minute * 55)
+	fails = 2
+	token = "b"
+	mu.Unlock()
+	get("a")
+	waitRefresh()
+	get("b")
+	if calls != 4 {
+		t.Fatalf("got %d fetches, want 4", calls)
+	}
+
+	// Refreshing fails until the token expires.
+	mu.Lock()
+	clock.Advance(time.Minute * 55)
+	fails = 1 << 30
+	mu.Unlock()
+	get("b")
+	waitRefresh()
+	if _, err := f.Get(ctx); err == nil {
+		t.Fatalf("expected error after token expired")
+	}
+}
+
+func TestRefresher_Expired(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock()
+	r := New(time.Second, time.Second)
+	r.MaxAttempts = 5
+	r.Clock = clock
+
+	var calls int
+	fetch := func(ctx context.Context) (string, time.Time, error) {
+		calls++
+		if calls == 1 {
+			return "a", clock.Now().Add(time.Minute), nil
+		}
+		return "", time.Time{}, errors.New("unavailable")
+	}
+	f := NewRefresher(fetch, r, 0)
+	defer f.Close()
+
+	ctx := context.Background()
+	if _, err := f.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Once the value has expired, refreshes get all their attempts.
+	clock.Advance(time.Hour)
+	if _, err := f.Get(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 6 {
+		t.Fatalf("got %d fetches, want 6", calls)
+	}
+}
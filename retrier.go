@@ -2,15 +2,24 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
+// ErrExhausted is returned by WaitErr once MaxAttempts attempts have been made.
+var ErrExhausted = errors.New("retry: attempts exhausted")
+
+// ErrDeadlineExceeded is returned by WaitErr when the next attempt would come
+// after MaxElapsed.
+var ErrDeadlineExceeded = errors.New("retry: max elapsed time exceeded")
+
 // Retrier implements an exponentially backing off retry instance.
 // Use New instead of creating this object directly.
 type Retrier struct {
-	// Delay is the current delay between attempts.
+	// Delay is the delay that preceded the most recent attempt.
 	Delay time.Duration
 
 	// Floor and Ceil are the minimum and maximum delays.
@@ -25,10 +34,165 @@ type Retrier struct {
 	// It is the standard deviation of the normal distribution of a random variable
 	// multiplied by the delay. E.g. 0.1 means the delay is normally distributed
 	// with a standard deviation of 10% of the delay. Floor and Ceil are still
-	// respected, making outlandish values impossible.
+	// respected, making outlandish values impossible: apart from the immediate
+	// first attempt, delays always fall within them.
 	//
 	// Jitter can help avoid thundering herds.
 	Jitter float64
+
+	// JitterDistribution is the distribution Jitter draws from. With
+	// JitterUniform, Jitter is instead the largest fraction by which the
+	// delay may vary either way, e.g. 0.1 means anywhere from 90% to 110%.
+	JitterDistribution Distribution
+
+	// Rand, if set, is the source of jitter in place of math/rand's global
+	// source. Clones share it, so retriers serialize their draws from it;
+	// it must not be used elsewhere while they run.
+	Rand *rand.Rand
+
+	// MaxAttempts, if positive, limits the number of attempts Wait allows
+	// between Resets. Zero means no limit.
+	MaxAttempts int
+
+	// MaxElapsed, if positive, limits how long Wait keeps allowing attempts
+	// after the first since the last Reset. WaitErr returns
+	// ErrDeadlineExceeded rather than sleep past it.
+	MaxElapsed time.Duration
+
+	// AdaptiveFloor, if set, raises Floor to track the latency of
+	// successful attempts run by Do. Clones share it.
+	AdaptiveFloor *LatencyFloor
+
+	// Breaker, if set, makes Wait fail fast with ErrBreakerOpen while it is
+	// open. Do records the outcome of every attempt in it; loops using Wait
+	// must do so themselves. Clones share it.
+	Breaker *Breaker
+
+	// Budget, if set, must have a token for every attempt after the first,
+	// or Wait fails with ErrBudgetExhausted. Do deposits into it on success;
+	// loops using Wait must do so themselves. Clones share it.
+	Budget *Budget
+
+	// Limiter, if set, gates every attempt, including the first, once the
+	// backoff delay has passed. Wait returns its error if it fails. If it
+	// has a Record(error) method, as AdaptiveLimiter does, Do calls it with
+	// the outcome of every attempt.
+	Limiter Limiter
+
+	// Coordinator, if set, may push each attempt after the first back to
+	// spread out the attempts of many processes. Wait returns its error if it
+	// fails.
+	Coordinator Coordinator
+
+	// Decay, if non-zero, undoes one step of growth for every Decay that
+	// passes between one Wait returning and the next being called. After a
+	// long enough quiet period, the retrier is effectively Reset. This keeps
+	// long-lived loops from staying at Ceil forever after one bad patch.
+	Decay time.Duration
+
+	// MinDelay, if positive, is the least Wait sleeps before any attempt but
+	// the first since the last Reset, whatever Floor, Ceil, Jitter, Decay
+	// or a delay requested with After say. It guards always-on loops against
+	// spinning when the other settings are wrong, e.g. a zero Floor.
+	MinDelay time.Duration
+
+	// HotLoopRate, if positive, is the most attempts per second Wait
+	// expects. When more are allowed within one second, counting across
+	// Resets, an EventHotLoop is sent to Events, once per second it lasts.
+	HotLoopRate float64
+
+	// Notify, if set, is called when an attempt run by Do fails and is about
+	// to be retried, with the attempt number (starting at 1), its error, and
+	// the delay before the next attempt.
+	Notify func(attempt int, err error, next time.Duration)
+
+	// Observers are called after Notify, in order, with the same arguments.
+	// Unlike Notify, which belongs to the caller, they let several add-ons
+	// such as loggers and metrics watch the same retrier. Use AddObserver to
+	// add one.
+	Observers []func(attempt int, err error, next time.Duration)
+
+	// Events, if set, receives an Event as Do starts, fails, backs off
+	// between and finishes attempts, and whenever attempts outpace
+	// HotLoopRate. Sends never block: events are dropped if the channel is
+	// full, so it should be buffered. It is never closed.
+	Events chan<- Event
+
+	// Stats, if set, summarizes the duration of the attempts run by Do and
+	// the time spent waiting before retries.
+	Stats *Stats
+
+	// AttemptTimeout, if positive, limits how long each attempt run by Do
+	// may take. The attempt's context is cancelled once it passes, and the
+	// attempt is retried like any other failure, while the context passed
+	// to Do still bounds the whole loop.
+	AttemptTimeout time.Duration
+
+	// RecoverPanics makes Do recover panics in attempts and treat them as
+	// failures with a *PanicError, so that one bad attempt doesn't take a
+	// long-running process down.
+	RecoverPanics bool
+
+	// HistorySize, if positive, is how many of the most recent errors
+	// returned by attempts run by Do are kept for History.
+	HistorySize int
+
+	// JoinErrors makes Do, when it gives up after several failed attempts,
+	// return their errors joined with errors.Join instead of just the last.
+	// Attempts can fail differently, and the last error may not tell the
+	// whole story.
+	JoinErrors bool
+
+	// WrapErrors makes Do wrap the error it gives up with in an
+	// AttemptsError, recording how many attempts it made and for how long.
+	WrapErrors bool
+
+	// Fallback, if set, is called with the error Do would return when it
+	// gives up, and what it returns is returned instead. It can supply a
+	// cached or default result so callers degrade gracefully.
+	Fallback func(ctx context.Context, err error) error
+
+	// Clock, if set, is used in place of the system clock. It is mostly
+	// useful in tests; see the retrytest package.
+	Clock Clock
+
+	// attempt is how far the delay has grown. It is the number of calls to
+	// Wait since the last Reset, less any Decay.
+	attempt int
+	// count is the number of attempts allowed since the last Reset.
+	count int
+	// wake is when the most recent Wait was due to return.
+	wake time.Time
+	// start is when the first Wait since the last Reset was called.
+	start time.Time
+	// history is a ring buffer of recent errors, and historyNext the index
+	// the next one goes in once it is full.
+	history     []error
+	historyNext int
+	// timer is reused by sleep.
+	timer *time.Timer
+	// hotStart is when the current second of HotLoopRate accounting began,
+	// and hotCount the number of attempts allowed since. Reset leaves them.
+	hotStart time.Time
+	hotCount int
+}
+
+// Clock tells the time and schedules wake-ups on behalf of a Retrier.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (r *Retrier) clock() Clock {
+	if r.Clock == nil {
+		return systemClock{}
+	}
+	return r.Clock
 }
 
 // New creates a retrier that exponentially backs off from floor to ceil pauses.
@@ -43,33 +207,272 @@ func New(floor, ceil time.Duration) *Retrier {
 	}
 }
 
-func applyJitter(d time.Duration, jitter float64) time.Duration {
-	if jitter == 0 {
+// Distribution is a probability distribution for jitter.
+type Distribution int
+
+const (
+	// JitterNormal draws from a normal distribution. It is the default.
+	JitterNormal Distribution = iota
+	// JitterUniform draws from a uniform distribution.
+	JitterUniform
+)
+
+// checkHotLoop counts an attempt due at t against HotLoopRate.
+func (r *Retrier) checkHotLoop(t time.Time) {
+	if r.HotLoopRate <= 0 {
+		return
+	}
+	if t.Sub(r.hotStart) >= time.Second {
+		r.hotStart, r.hotCount = t, 0
+	}
+	r.hotCount++
+	if float64(r.hotCount) > r.HotLoopRate && float64(r.hotCount-1) <= r.HotLoopRate {
+		r.emit(Event{Kind: EventHotLoop, Attempt: r.count})
+	}
+}
+
+// randMu guards draws from Retrier.Rand, which clones share.
+var randMu sync.Mutex
+
+// draw returns a jitter factor from r's JitterDistribution.
+func (r *Retrier) draw() float64 {
+	norm, uniform := rand.NormFloat64, rand.Float64
+	if r.Rand != nil {
+		randMu.Lock()
+		defer randMu.Unlock()
+		norm, uniform = r.Rand.NormFloat64, r.Rand.Float64
+	}
+	if r.JitterDistribution == JitterUniform {
+		return 2*uniform() - 1
+	}
+	return norm()
+}
+
+// applyJitter applies r's jitter to d, keeping the result within the floor
+// and Ceil. A zero delay stays zero.
+func (r *Retrier) applyJitter(d time.Duration) time.Duration {
+	if r.Jitter == 0 || d == 0 {
 		return d
 	}
-	d *= time.Duration(1 + jitter*rand.NormFloat64())
-	if d < 0 {
+	x := r.draw()
+	// Scale in floating point: converting the factor to a Duration first
+	// would truncate it to a whole number.
+	f := float64(d) * (1 + r.Jitter*x)
+	if floor := r.floor(); f < float64(floor) {
+		f = float64(floor)
+	}
+	return saturate(f, r.Ceil)
+}
+
+// saturate converts f nanoseconds to a Duration no greater than max, and no
+// less than zero. Converting a float64 beyond the range of a Duration would
+// wrap around, possibly to a negative delay.
+func saturate(f float64, max time.Duration) time.Duration {
+	// Written so that NaN saturates too.
+	if !(f < float64(max)) {
+		return max
+	}
+	if f <= 0 {
 		return 0
 	}
-	return d
+	return time.Duration(f)
 }
 
-// Wait returns after min(Delay*Growth, Ceil) or ctx is cancelled.
+// Wait returns after the backoff delay or ctx is cancelled.
 // The first call to Wait will return immediately.
+//
+// The delay before attempt n, counting the immediate first attempt as 0, is
+// Floor * Rate^n capped at Ceil, with Jitter then applied.
 func (r *Retrier) Wait(ctx context.Context) bool {
-	r.Delay = time.Duration(float64(r.Delay) * r.Rate)
+	return r.WaitErr(ctx) == nil
+}
+
+// WaitErr is like Wait, but returns why no further attempt should be made
+// instead of false. A nil error means the caller should try again.
+func (r *Retrier) WaitErr(ctx context.Context) error {
+	d, err := r.next()
+	if err != nil {
+		return err
+	}
+	return r.pause(ctx, d)
+}
+
+// pause sleeps for d and then checks that the attempt may go ahead.
+func (r *Retrier) pause(ctx context.Context, d time.Duration) error {
+	return r.pauseOn(ctx, d, r.count > 1, r.sleep)
+}
+
+// pauseOn is pause for callers that share r between goroutines: retry
+// reports whether the attempt is a retry, as read alongside next, and sleep
+// does the waiting, since r's own timer can't be shared.
+func (r *Retrier) pauseOn(ctx context.Context, d time.Duration, retry bool, sleep func(context.Context, time.Duration) bool) error {
+	// Check the budget before sleeping, since there's no point waiting for
+	// a retry that can't be made.
+	if r.Budget != nil && retry && !r.Budget.Withdraw() {
+		return ErrBudgetExhausted
+	}
+	// The first attempt is made right away, like it is without one.
+	if r.Coordinator != nil && retry {
+		now := r.clock().Now()
+		at, err := r.Coordinator.Schedule(ctx, now.Add(d))
+		if err != nil {
+			return err
+		}
+		d = at.Sub(now)
+	}
+	if !sleep(ctx, d) {
+		return ctx.Err()
+	}
+	if r.Limiter != nil {
+		if err := r.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	// The breaker comes last, as a trial attempt it allows must be made.
+	if r.Breaker != nil {
+		if err := r.Breaker.Allow(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// C returns a channel that receives a value each time an attempt should be
+// made, following the same schedule as Wait. The next delay starts once the
+// previous value has been received. The channel is closed when ctx is
+// cancelled, which must happen eventually to release the goroutine feeding it.
+//
+// r must not be used by anything else until the channel is closed.
+func (r *Retrier) C(ctx context.Context) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		defer close(c)
+		for r.Wait(ctx) {
+			select {
+			case c <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// floor returns the smallest delay, taking AdaptiveFloor into account.
+func (r *Retrier) floor() time.Duration {
+	floor := r.Floor
+	if r.AdaptiveFloor != nil {
+		if f := r.AdaptiveFloor.Floor(); f > floor {
+			floor = f
+		}
+	}
+	return floor
+}
 
-	r.Delay = applyJitter(r.Delay, r.Jitter)
+// backoff returns the delay before the given attempt, without jitter.
+func (r *Retrier) backoff(attempt int) time.Duration {
+	if attempt == 0 {
+		return 0
+	}
+	return saturate(float64(r.floor())*math.Pow(r.Rate, float64(attempt)), r.Ceil)
+}
+
+// next advances the retrier and returns how long to sleep before the next
+// attempt, or an error if no further attempts are allowed.
+func (r *Retrier) next() (time.Duration, error) {
+	if r.MaxAttempts > 0 && r.count >= r.MaxAttempts {
+		return 0, ErrExhausted
+	}
 
-	if r.Delay > r.Ceil {
-		r.Delay = r.Ceil
+	now := r.clock().Now()
+	if r.start.IsZero() {
+		r.start = now
 	}
+	r.decay(now)
+
+	d := r.backoff(r.attempt)
+	d = r.applyJitter(d)
 
+	if d > r.Ceil {
+		d = r.Ceil
+	}
+	if r.count > 0 && d < r.MinDelay {
+		d = r.MinDelay
+	}
+	if r.MaxElapsed > 0 && now.Add(d).Sub(r.start) > r.MaxElapsed {
+		return 0, ErrDeadlineExceeded
+	}
+	r.attempt++
+	r.count++
+	r.checkHotLoop(now.Add(d))
+
+	r.Delay = d
+	r.wake = now.Add(d)
+	return d, nil
+}
+
+// decay winds back the attempt count by the number of Decay periods that
+// have passed since the last Wait.
+func (r *Retrier) decay(now time.Time) {
+	if r.Decay <= 0 || r.wake.IsZero() {
+		return
+	}
+	idle := now.Sub(r.wake)
+	if idle < r.Decay {
+		return
+	}
+	steps := idle / r.Decay
+	if steps >= time.Duration(r.attempt) {
+		r.attempt = 0
+		return
+	}
+	r.attempt -= int(steps)
+}
+
+// sleep waits for d or until ctx is cancelled, reporting whether the full
+// delay elapsed. With the system clock, it reuses one timer across calls
+// rather than allocate a new one each time, and stops it if ctx is
+// cancelled so it doesn't linger until it fires.
+func (r *Retrier) sleep(ctx context.Context, d time.Duration) bool {
+	if r.Clock != nil || ctx.Err() != nil {
+		return sleepOn(ctx, r.clock(), d)
+	}
+	if d <= 0 {
+		// Not worth a timer, which most first attempts would otherwise
+		// allocate.
+		return true
+	}
+	if r.timer == nil {
+		r.timer = time.NewTimer(d)
+	} else {
+		r.timer.Reset(d)
+	}
 	select {
-	case <-time.After(r.Delay):
-		if r.Delay < r.Floor {
-			r.Delay = r.Floor
+	case <-r.timer.C:
+		return true
+	case <-ctx.Done():
+		if !r.timer.Stop() {
+			// Drain a value that arrived regardless, so the next Reset
+			// starts clean.
+			select {
+			case <-r.timer.C:
+			default:
+			}
 		}
+		return false
+	}
+}
+
+// sleepOn is like sleep, but uses clock.After. It is safe for concurrent use
+// if clock is.
+func sleepOn(ctx context.Context, clock Clock, d time.Duration) bool {
+	// A zero delay is ready immediately, so select could pick it over a
+	// cancelled context.
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-clock.After(d):
 		return true
 	case <-ctx.Done():
 		return false
@@ -79,4 +482,61 @@ func (r *Retrier) Wait(ctx context.Context) bool {
 // Reset resets the retrier to its initial state.
 func (r *Retrier) Reset() {
 	r.Delay = 0
+	r.attempt = 0
+	r.count = 0
+	r.wake = time.Time{}
+	r.start = time.Time{}
+	r.history = nil
+	r.historyNext = 0
+}
+
+// History returns up to HistorySize of the most recent errors from attempts
+// run by Do since the last Reset, oldest first.
+func (r *Retrier) History() []error {
+	h := make([]error, 0, len(r.history))
+	h = append(h, r.history[r.historyNext:]...)
+	return append(h, r.history[:r.historyNext]...)
+}
+
+// remember adds err to the history.
+func (r *Retrier) remember(err error) {
+	if r.HistorySize <= 0 {
+		return
+	}
+	if len(r.history) < r.HistorySize {
+		r.history = append(r.history, err)
+		return
+	}
+	r.history[r.historyNext] = err
+	r.historyNext = (r.historyNext + 1) % len(r.history)
+}
+
+// Clone returns a retrier with the same configuration as r in its initial
+// state. It lets a template retrier be declared once and copied for each
+// request or goroutine.
+func (r *Retrier) Clone() *Retrier {
+	c := *r
+	c.Reset()
+	c.timer = nil
+	c.hotStart, c.hotCount = time.Time{}, 0
+	// Observers added to the clone must not land in r's backing array.
+	c.Observers = c.Observers[:len(c.Observers):len(c.Observers)]
+	return &c
+}
+
+// AddObserver appends fn to r's Observers.
+func (r *Retrier) AddObserver(fn func(attempt int, err error, next time.Duration)) {
+	r.Observers = append(r.Observers, fn)
+}
+
+// PeekDelay returns the delay the next Wait will sleep for, before jitter,
+// without advancing r. It lets wrappers show when the next attempt is due.
+func (r *Retrier) PeekDelay() time.Duration {
+	c := *r
+	c.decay(c.clock().Now())
+	d := c.backoff(c.attempt)
+	if c.count > 0 && d < c.MinDelay {
+		d = c.MinDelay
+	}
+	return d
 }
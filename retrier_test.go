@@ -2,7 +2,11 @@ package retry
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -52,6 +56,36 @@ func TestScalesExponentially(t *testing.T) {
 	}
 }
 
+func TestGrowth(t *testing.T) {
+	const (
+		floor = time.Second
+		ceil  = time.Minute
+	)
+	for _, rate := range []float64{1, 1.5, math.Phi, 2, 3} {
+		rate := rate
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			r := New(floor, ceil)
+			r.Rate = rate
+
+			for i := 0; i < 20; i++ {
+				want := time.Duration(0)
+				if i > 0 {
+					want = time.Duration(float64(floor) * math.Pow(rate, float64(i)))
+				}
+				if want > ceil {
+					want = ceil
+				}
+				if got, _ := r.next(); got != want {
+					t.Fatalf("attempt %d: got %v, want %v", i, got, want)
+				}
+				if r.Delay != want {
+					t.Fatalf("attempt %d: Delay is %v, want %v", i, r.Delay, want)
+				}
+			}
+		})
+	}
+}
+
 func TestReset(t *testing.T) {
 	r := New(time.Hour, time.Hour)
 	// Should be immediate
@@ -91,6 +125,87 @@ func TestJitter_Normal(t *testing.T) {
 	t.Logf("sample: %v", waits[len(waits)-10:])
 }
 
+func TestJitter_Seeded(t *testing.T) {
+	delays := func() []time.Duration {
+		r := NewRetrier(
+			WithFloor(time.Second),
+			WithCeil(time.Hour),
+			WithJitter(0.5),
+			WithRand(rand.New(rand.NewSource(42))),
+		)
+		var ds []time.Duration
+		for i := 0; i < 10; i++ {
+			d, err := r.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ds = append(ds, d)
+		}
+		return ds
+	}
+
+	a, b := delays(), delays()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("delay %d: %v then %v with the same seed", i, a[i], b[i])
+		}
+	}
+}
+
+func TestJitter_Uniform(t *testing.T) {
+	r := NewRetrier(
+		WithFloor(time.Second),
+		WithCeil(time.Hour),
+		WithRate(1.1),
+		WithJitter(0.5),
+		WithJitterDistribution(JitterUniform),
+		WithRand(rand.New(rand.NewSource(1))),
+	)
+	for i := 0; i < 50; i++ {
+		base := r.backoff(r.attempt)
+		d, err := r.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if float64(d) < 0.5*float64(base) || float64(d) > 1.5*float64(base) {
+			t.Fatalf("attempt %d: delay %v outside 50%% of %v", i, d, base)
+		}
+	}
+}
+
+func TestJitter_Bounds(t *testing.T) {
+	r := NewRetrier(
+		WithFloor(time.Second),
+		WithCeil(10*time.Second),
+		WithRate(2),
+		WithJitter(3),
+		WithRand(rand.New(rand.NewSource(1))),
+	)
+
+	if d, _ := r.next(); d != 0 {
+		t.Fatalf("first attempt delayed by %v", d)
+	}
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		d, err := r.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d < r.Floor || d > r.Ceil {
+			t.Fatalf("attempt %d: delay %v outside [%v, %v]", i+1, d, r.Floor, r.Ceil)
+		}
+		seen[d] = true
+		if i == 10 {
+			r.Reset()
+			r.next()
+		}
+	}
+	// Jitter must not be truncated to whole multiples of the delay.
+	if len(seen) < 10 {
+		t.Fatalf("only %d distinct delays: %v", len(seen), seen)
+	}
+}
+
 // stdDev returns the standard deviation of the sample.
 func stdDev(sample []float64) float64 {
 	if len(sample) == 0 {
@@ -110,3 +225,392 @@ func stdDev(sample []float64) float64 {
 
 	return math.Sqrt(variance)
 }
+
+func TestClone(t *testing.T) {
+	r := New(time.Second, time.Minute)
+	r.Rate = 3
+	r.Jitter = 0.2
+	r.Wait(context.Background())
+
+	c := r.Clone()
+	if c == r {
+		t.Fatalf("clone is the same retrier")
+	}
+	if c.Delay != 0 {
+		t.Fatalf("clone delay not reset: %v", c.Delay)
+	}
+	if c.Floor != r.Floor || c.Ceil != r.Ceil || c.Rate != r.Rate || c.Jitter != r.Jitter {
+		t.Fatalf("clone configuration differs: %+v vs %+v", c, r)
+	}
+	if r.attempt != 1 {
+		t.Fatalf("original retrier modified: %v", r.attempt)
+	}
+}
+
+// testClock is a Clock whose time only moves when told to. Its timers fire
+// immediately.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Unix(0, 0)}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Elapsed returns how far the clock has moved.
+func (c *testClock) Elapsed() time.Duration {
+	return c.Now().Sub(time.Unix(0, 0))
+}
+
+func TestDecay(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Hour)
+	r.Rate = 2
+	r.Decay = time.Minute
+	r.Clock = clock
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		r.Wait(ctx)
+	}
+	if r.Delay != time.Second*16 {
+		t.Fatalf("unexpected delay: %v", r.Delay)
+	}
+
+	// Quiet for less than Decay, keep growing.
+	clock.Advance(time.Second * 59)
+	r.Wait(ctx)
+	if r.Delay != time.Second*32 {
+		t.Fatalf("decayed too early: %v", r.Delay)
+	}
+
+	// Two periods undo two steps.
+	clock.Advance(time.Minute * 2)
+	r.Wait(ctx)
+	if r.Delay != time.Second*16 {
+		t.Fatalf("did not decay two steps: %v", r.Delay)
+	}
+
+	// A long enough quiet period is as good as a Reset.
+	clock.Advance(time.Hour)
+	r.Wait(ctx)
+	if r.Delay != 0 {
+		t.Fatalf("did not decay fully: %v", r.Delay)
+	}
+}
+
+func TestWaitErr(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := New(time.Hour, time.Hour)
+	if err := r.WaitErr(ctx); err != nil {
+		t.Fatalf("first attempt not allowed: %v", err)
+	}
+	cancel()
+	if err := r.WaitErr(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := r.WaitErr(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := New(time.Millisecond, time.Millisecond*10)
+
+	c := r.C(ctx)
+	for i := 0; i < 3; i++ {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				t.Fatalf("channel closed early")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("attempt %d not signalled", i)
+		}
+	}
+
+	cancel()
+	for range c {
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+
+	ctx := context.Background()
+	var n int
+	for r.Wait(ctx) {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("got %d attempts, want 3", n)
+	}
+	if err := r.WaitErr(ctx); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("got %v, want %v", err, ErrExhausted)
+	}
+
+	r.Reset()
+	if !r.Wait(ctx) {
+		t.Fatalf("attempt not allowed after Reset")
+	}
+}
+
+func TestMaxElapsed(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Second)
+	r.MaxElapsed = 2500 * time.Millisecond
+	r.Clock = clock
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := r.WaitErr(ctx); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	// Sleeping another second would pass MaxElapsed.
+	if err := r.WaitErr(ctx); !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrDeadlineExceeded)
+	}
+	if got := clock.Elapsed(); got != 2*time.Second {
+		t.Fatalf("slept %v, want 2s", got)
+	}
+
+	r.Reset()
+	if err := r.WaitErr(ctx); err != nil {
+		t.Fatalf("after Reset: %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	var errs []error
+	for i := 0; i < 5; i++ {
+		errs = append(errs, fmt.Errorf("error %d", i))
+	}
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 5
+	r.HistorySize = 3
+	var calls int
+	_, _ = FuncCtx[int](func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errs[calls-1]
+	}).Do(context.Background(), r)
+
+	got := r.History()
+	if len(got) != 3 {
+		t.Fatalf("got %d errors, want 3", len(got))
+	}
+	for i, want := range errs[2:] {
+		if got[i] != want {
+			t.Errorf("error %d: got %v, want %v", i, got[i], want)
+		}
+	}
+
+	r.Reset()
+	if len(r.History()) != 0 {
+		t.Fatal("Reset kept the history")
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	for name, r := range map[string]*Retrier{
+		"max ceil":  NewRetrier(WithFloor(time.Hour), WithCeil(math.MaxInt64), WithRate(2)),
+		"jitter":    NewRetrier(WithFloor(time.Hour), WithCeil(math.MaxInt64), WithRate(10), WithJitter(0.5)),
+		"huge rate": NewRetrier(WithFloor(math.MaxInt64/2), WithCeil(math.MaxInt64), WithRate(math.MaxFloat64)),
+		"NaN rate":  NewRetrier(WithFloor(time.Second), WithCeil(time.Minute), WithRate(math.NaN())),
+	} {
+		var prev time.Duration
+		for i := 0; i < 200; i++ {
+			d, err := r.next()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if d < 0 || d > r.Ceil {
+				t.Fatalf("%s: attempt %d: delay %v outside [0, %v]", name, i+1, d, r.Ceil)
+			}
+			if r.Jitter == 0 && d < prev {
+				t.Fatalf("%s: attempt %d: delay shrank from %v to %v", name, i+1, prev, d)
+			}
+			prev = d
+		}
+		if r.Jitter == 0 && prev != r.Ceil {
+			t.Fatalf("%s: delay settled at %v, want Ceil", name, prev)
+		}
+	}
+}
+
+func TestSleep_ReusesTimer(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if !r.sleep(ctx, time.Millisecond) {
+			t.Fatal("sleep failed")
+		}
+	}
+	timer := r.timer
+
+	// A cancelled sleep leaves the timer ready for the next one.
+	cctx, cancel := context.WithCancel(ctx)
+	go cancel()
+	if r.sleep(cctx, time.Hour) {
+		t.Fatal("sleep outlasted cancellation")
+	}
+	if !r.sleep(ctx, time.Millisecond) {
+		t.Fatal("sleep failed after cancellation")
+	}
+	if r.timer != timer {
+		t.Fatal("timer replaced")
+	}
+
+	if r.Clone().timer != nil {
+		t.Fatal("clone shares the timer")
+	}
+}
+
+func BenchmarkWait(b *testing.B) {
+	r := New(0, 0)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Wait(ctx)
+	}
+}
+
+func TestWait_Allocs(t *testing.T) {
+	r := New(0, 0)
+	ctx := context.Background()
+	if n := testing.AllocsPerRun(100, func() { r.Wait(ctx) }); n != 0 {
+		t.Fatalf("Wait allocates %v times", n)
+	}
+}
+
+func TestPeekDelay(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Hour)
+	r.Rate = 2
+	r.Decay = time.Minute
+	r.Clock = clock
+
+	ctx := context.Background()
+	if d := r.PeekDelay(); d != 0 {
+		t.Fatalf("first attempt: got %v, want 0", d)
+	}
+	for i := 0; i < 3; i++ {
+		peeked := r.PeekDelay()
+		r.Wait(ctx)
+		if r.Delay != peeked {
+			t.Fatalf("attempt %d: peeked %v, slept %v", i+1, peeked, r.Delay)
+		}
+	}
+	// Peeking doesn't advance the retrier.
+	if a, b := r.PeekDelay(), r.PeekDelay(); a != b || a != 8*time.Second {
+		t.Fatalf("got %v then %v, want 8s", a, b)
+	}
+	// It accounts for decay.
+	clock.Advance(time.Minute)
+	if d := r.PeekDelay(); d != 4*time.Second {
+		t.Fatalf("after decay: got %v, want 4s", d)
+	}
+}
+
+func TestPeekDelay_MinDelay(t *testing.T) {
+	clock := newTestClock()
+	r := New(0, 0)
+	r.MinDelay = time.Second
+	r.Clock = clock
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		peeked := r.PeekDelay()
+		r.Wait(ctx)
+		if r.Delay != peeked {
+			t.Fatalf("attempt %d: peeked %v, slept %v", i+1, peeked, r.Delay)
+		}
+	}
+	if d := r.PeekDelay(); d != time.Second {
+		t.Fatalf("got %v, want 1s", d)
+	}
+}
+
+func TestMinDelay(t *testing.T) {
+	clock := newTestClock()
+	r := New(0, 0)
+	r.MinDelay = time.Second
+	r.Clock = clock
+
+	ctx := context.Background()
+	r.Wait(ctx)
+	if clock.Elapsed() != 0 {
+		t.Fatalf("first attempt waited %v", clock.Elapsed())
+	}
+	r.Wait(ctx)
+	r.Wait(ctx)
+	if got := clock.Elapsed(); got != time.Second*2 {
+		t.Fatalf("waited %v, want 2s", got)
+	}
+}
+
+func TestHotLoopRate(t *testing.T) {
+	clock := newTestClock()
+	events := make(chan Event, 10)
+	r := New(0, 0)
+	r.HotLoopRate = 2
+	r.Events = events
+	r.Clock = clock
+
+	// Resets don't hide a hot loop.
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		r.Wait(ctx)
+		r.Reset()
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if e := <-events; e.Kind != EventHotLoop {
+		t.Fatalf("got %v event", e.Kind)
+	}
+
+	// A loop within the rate is left alone.
+	clock.Advance(time.Second)
+	r.MinDelay = time.Second
+	for i := 0; i < 5; i++ {
+		r.Wait(ctx)
+	}
+	if len(events) != 0 {
+		t.Fatalf("got %d events, want none", len(events))
+	}
+}
@@ -0,0 +1,23 @@
+package retry
+
+import "errors"
+
+// Retryable is implemented by errors that know whether the operation that
+// produced them is worth retrying.
+type Retryable interface {
+	Retryable() bool
+}
+
+// IsRetryable reports whether err is worth retrying. It uses the first error
+// in err's chain that implements Retryable. Errors that don't say are assumed
+// to be retryable; nil is not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r Retryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return true
+}
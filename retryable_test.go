@@ -0,0 +1,47 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string   { return fmt.Sprintf("retryable: %v", bool(e)) }
+func (e retryableError) Retryable() bool { return bool(e) }
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("plain"), true},
+		{retryableError(true), true},
+		{retryableError(false), false},
+		{fmt.Errorf("wrapped: %w", retryableError(false)), false},
+	} {
+		if got := IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v): got %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestDo_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("bad request: %w", retryableError(false))
+	}, WithFloor(time.Millisecond), WithCeil(time.Millisecond))
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+	if IsRetryable(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package retryexpvar publishes retry statistics with expvar, for services
+// without a metrics stack.
+package retryexpvar
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// Stats publishes, for each retry policy, counts of attempts, retries and
+// give-ups, the total seconds spent backing off, and the seconds of backoff
+// being waited out right now.
+type Stats struct {
+	m  *expvar.Map
+	mu sync.Mutex
+}
+
+// NewStats publishes stats as an expvar.Map under name. Like
+// expvar.Publish, it panics if name is already in use.
+func NewStats(name string) *Stats {
+	return &Stats{m: expvar.NewMap(name)}
+}
+
+// policy returns the map for the named policy, creating it if needed.
+func (s *Stats) policy(name string) *expvar.Map {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.m.Get(name).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map).Init()
+	s.m.Set(name, m)
+	return m
+}
+
+// Do calls fn under a clone of r like Retrier.Do, recording stats under
+// policy.
+func (s *Stats) Do(ctx context.Context, policy string, r *retry.Retrier, fn func(ctx context.Context) error) error {
+	m := s.policy(policy)
+
+	// The delay being slept, counted until the next attempt or until Do
+	// gives up during it.
+	var asleep float64
+	wake := func() {
+		m.AddFloat("sleeping_seconds", -asleep)
+		asleep = 0
+	}
+
+	r = r.Clone()
+	r.AddObserver(func(attempt int, err error, next time.Duration) {
+		m.Add("retries", 1)
+		m.AddFloat("backoff_seconds", next.Seconds())
+		asleep = next.Seconds()
+		m.AddFloat("sleeping_seconds", asleep)
+	})
+
+	err := r.Do(ctx, func(ctx context.Context) error {
+		wake()
+		m.Add("attempts", 1)
+		return fn(ctx)
+	})
+	wake()
+	if err != nil {
+		m.Add("give_ups", 1)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package retryexpvar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// runs makes the expvar names unique across -count runs, since expvar
+// panics on reuse.
+var runs atomic.Int64
+
+func TestStats(t *testing.T) {
+	name := fmt.Sprintf("%s_%d", t.Name(), runs.Add(1))
+	s := NewStats(name)
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	ctx := context.Background()
+	fail := errors.New("fail")
+	if err := s.Do(ctx, "flaky", r, func(ctx context.Context) error { return fail }); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if err := s.Do(ctx, "flaky", r, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"attempts":         4,
+		"retries":          2,
+		"give_ups":         1,
+		"backoff_seconds":  0.002,
+		"sleeping_seconds": 0,
+	}
+	for k, v := range want {
+		if got["flaky"][k] != v {
+			t.Errorf("%s: got %v, want %v", k, got["flaky"][k], v)
+		}
+	}
+}
+
+func TestStats_Sleeping(t *testing.T) {
+	name := fmt.Sprintf("%s_%d", t.Name(), runs.Add(1))
+	s := NewStats(name)
+	sleeping := func() float64 {
+		v, _ := s.policy("down").Get("sleeping_seconds").(*expvar.Float)
+		if v == nil {
+			return 0
+		}
+		return v.Value()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Do(ctx, "down", retry.New(time.Hour, time.Hour), func(ctx context.Context) error {
+			return errors.New("fail")
+		})
+	}()
+
+	// The hour-long backoff shows until Do gives up on it.
+	for sleeping() != 3600 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := sleeping(); got != 0 {
+		t.Fatalf("got %v seconds sleeping after Do returned, want 0", got)
+	}
+}
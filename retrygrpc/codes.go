@@ -0,0 +1,33 @@
+package retrygrpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OnCodes returns a condition that holds for errors carrying any of the given
+// status codes, e.g. OnCodes(codes.Unavailable, codes.DeadlineExceeded).
+// Wrapped status errors are recognized.
+func OnCodes(cs ...codes.Code) func(err error) bool {
+	return func(err error) bool {
+		if err == nil {
+			return false
+		}
+		code := status.Code(err)
+		for _, c := range cs {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NotOnCodes returns a condition that holds for errors carrying none of the
+// given status codes.
+func NotOnCodes(cs ...codes.Code) func(err error) bool {
+	on := OnCodes(cs...)
+	return func(err error) bool {
+		return err != nil && !on(err)
+	}
+}
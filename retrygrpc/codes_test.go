@@ -0,0 +1,34 @@
+package retrygrpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOnCodes(t *testing.T) {
+	on := OnCodes(codes.Unavailable, codes.DeadlineExceeded)
+	notOn := NotOnCodes(codes.Unavailable, codes.DeadlineExceeded)
+
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{status.Error(codes.Unavailable, "down"), true},
+		{status.Error(codes.DeadlineExceeded, "slow"), true},
+		{fmt.Errorf("call: %w", status.Error(codes.Unavailable, "down")), true},
+		{status.Error(codes.NotFound, "missing"), false},
+		{errors.New("plain"), false},
+	} {
+		if got := on(tc.err); got != tc.want {
+			t.Errorf("OnCodes(%v): got %v, want %v", tc.err, got, tc.want)
+		}
+		if got := notOn(tc.err); got != (tc.err != nil && !tc.want) {
+			t.Errorf("NotOnCodes(%v): got %v", tc.err, got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/coder/retry/retrygrpc
+
+go 1.20
+
+require (
+	github.com/coder/retry v1.5.2-0.20261016015639-1bd2fdbe9880
+	google.golang.org/grpc v1.60.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.16.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231002182017-d307bd883b97 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
+
+replace github.com/coder/retry => ../
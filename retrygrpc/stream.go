@@ -0,0 +1,177 @@
+// Package retrygrpc provides retry helpers for gRPC clients. It is a separate
+// module so that users of retry don't depend on gRPC.
+package retrygrpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/coder/retry"
+)
+
+// ReplayFunc restores the state of a subscription on a freshly established
+// stream, typically by sending the request that started it.
+type ReplayFunc func(ctx context.Context, method string, s grpc.ClientStream) error
+
+// StreamInterceptor returns a client interceptor that transparently
+// re-establishes server streams that break with codes.Unavailable. Each
+// stream backs off between reconnects according to a clone of r.
+//
+// Once a stream is re-established, replay is called to restore its state. If
+// replay is nil, every message sent on the stream so far is sent again and,
+// if CloseSend had been called, the send direction is closed again. That suits
+// server-streaming subscriptions, but not long-lived bidirectional streams,
+// whose sends would accumulate.
+//
+// Messages the server sent between the break and the reconnect are lost, so
+// replay should resume from the last message received where the protocol
+// allows it.
+func StreamInterceptor(r *retry.Retrier, replay ReplayFunc) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || !desc.ServerStreams {
+			return cs, err
+		}
+		return &stream{
+			ctx: ctx,
+			dial: func(ctx context.Context) (grpc.ClientStream, error) {
+				return streamer(ctx, desc, cc, method, opts...)
+			},
+			method: method,
+			r:      r.Clone(),
+			replay: replay,
+			cs:     cs,
+		}, nil
+	}
+}
+
+// transient reports whether err means a stream should be re-established.
+var transient = OnCodes(codes.Unavailable)
+
+// stream is a grpc.ClientStream that re-dials itself when broken.
+type stream struct {
+	ctx    context.Context
+	dial   func(ctx context.Context) (grpc.ClientStream, error)
+	method string
+	r      *retry.Retrier
+	replay ReplayFunc
+
+	// fresh is set while the current stream, re-established by reconnect,
+	// has delivered no message. The backoff is only reset once it does, so
+	// a stream that breaks straight away isn't re-opened in a hot loop.
+	fresh atomic.Bool
+
+	mu sync.Mutex
+	cs grpc.ClientStream
+	// sent and closed record what replay needs to resend when replay is
+	// nil.
+	sent   []interface{}
+	closed bool
+}
+
+var _ grpc.ClientStream = (*stream)(nil)
+
+func (s *stream) current() grpc.ClientStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cs
+}
+
+func (s *stream) Header() (metadata.MD, error) { return s.current().Header() }
+func (s *stream) Trailer() metadata.MD         { return s.current().Trailer() }
+func (s *stream) Context() context.Context     { return s.current().Context() }
+
+func (s *stream) CloseSend() error {
+	s.mu.Lock()
+	s.closed = true
+	cs := s.cs
+	s.mu.Unlock()
+	return cs.CloseSend()
+}
+
+func (s *stream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	if s.replay == nil {
+		s.sent = append(s.sent, m)
+	}
+	cs := s.cs
+	s.mu.Unlock()
+	// A broken stream makes SendMsg return io.EOF, leaving RecvMsg to
+	// discover why and reconnect.
+	return cs.SendMsg(m)
+}
+
+func (s *stream) RecvMsg(m interface{}) error {
+	for {
+		cs := s.current()
+		err := cs.RecvMsg(m)
+		if err == nil && s.fresh.CompareAndSwap(true, false) {
+			s.mu.Lock()
+			s.r.Reset()
+			s.mu.Unlock()
+		}
+		if !transient(err) {
+			return err
+		}
+		if err := s.reconnect(cs, err); err != nil {
+			return err
+		}
+	}
+}
+
+// reconnect replaces broken, which failed with cause, with a new stream,
+// unless another goroutine has already done so. The backoff carries on from
+// earlier reconnects until a message arrives.
+func (s *stream) reconnect(broken grpc.ClientStream, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cs != broken {
+		return nil
+	}
+
+	err := cause
+	for {
+		if werr := s.r.WaitErr(s.ctx); werr != nil {
+			if s.ctx.Err() != nil {
+				return status.FromContextError(s.ctx.Err()).Err()
+			}
+			return err
+		}
+		var cs grpc.ClientStream
+		cs, err = s.dial(s.ctx)
+		if err == nil {
+			err = s.restore(cs)
+		}
+		if err == nil {
+			s.cs = cs
+			s.fresh.Store(true)
+			return nil
+		}
+		if !transient(err) {
+			return err
+		}
+	}
+}
+
+// restore brings a new stream up to the state of the broken one. s.mu must
+// be held.
+func (s *stream) restore(cs grpc.ClientStream) error {
+	if s.replay != nil {
+		return s.replay(s.ctx, s.method, cs)
+	}
+	for _, m := range s.sent {
+		if err := cs.SendMsg(m); err != nil {
+			return err
+		}
+	}
+	if s.closed {
+		return cs.CloseSend()
+	}
+	return nil
+}
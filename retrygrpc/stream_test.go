@@ -0,0 +1,165 @@
+package retrygrpc
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/coder/retry"
+)
+
+// fakeStream delivers msgs and then fails with err.
+type fakeStream struct {
+	msgs []string
+	err  error
+	sent []interface{}
+	done bool
+}
+
+func (f *fakeStream) Header() (metadata.MD, error) { return nil, nil }
+func (f *fakeStream) Trailer() metadata.MD         { return nil }
+func (f *fakeStream) Context() context.Context     { return context.Background() }
+func (f *fakeStream) CloseSend() error             { f.done = true; return nil }
+
+func (f *fakeStream) SendMsg(m interface{}) error {
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func (f *fakeStream) RecvMsg(m interface{}) error {
+	if len(f.msgs) == 0 {
+		return f.err
+	}
+	*m.(*string) = f.msgs[0]
+	f.msgs = f.msgs[1:]
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	streams := []*fakeStream{
+		{msgs: []string{"a", "b"}, err: unavailable},
+		nil, // dialling fails once
+		{msgs: []string{"c"}, err: io.EOF},
+	}
+	var dials int
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		s := streams[dials]
+		dials++
+		if s == nil {
+			return nil, unavailable
+		}
+		return s, nil
+	}
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	intercept := StreamInterceptor(r, nil)
+
+	ctx := context.Background()
+	cs, err := intercept(ctx, &grpc.StreamDesc{ServerStreams: true}, nil, "/svc/Watch", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.SendMsg("subscribe"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		var m string
+		err := cs.RecvMsg(&m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, m)
+	}
+
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("got messages %v", got)
+	}
+	if dials != 3 {
+		t.Fatalf("got %d dials, want 3", dials)
+	}
+	last := streams[2]
+	if len(last.sent) != 1 || last.sent[0] != "subscribe" || !last.done {
+		t.Fatalf("subscription not replayed: sent %v, closed %v", last.sent, last.done)
+	}
+}
+
+func TestStreamInterceptor_Replay(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	streams := []*fakeStream{
+		{msgs: []string{"a"}, err: unavailable},
+		{err: status.Error(codes.PermissionDenied, "denied")},
+	}
+	var dials int
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		s := streams[dials]
+		dials++
+		return s, nil
+	}
+
+	var replayed []string
+	replay := func(ctx context.Context, method string, s grpc.ClientStream) error {
+		replayed = append(replayed, method)
+		return s.SendMsg("resume")
+	}
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	cs, err := StreamInterceptor(r, replay)(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/svc/Watch", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m string
+	if err := cs.RecvMsg(&m); err != nil || m != "a" {
+		t.Fatalf("got (%q, %v)", m, err)
+	}
+	if err := cs.RecvMsg(&m); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("got %v, want PermissionDenied", err)
+	}
+	if len(replayed) != 1 || replayed[0] != "/svc/Watch" {
+		t.Fatalf("replay calls: %v", replayed)
+	}
+	if sent := streams[1].sent; len(sent) != 1 || sent[0] != "resume" {
+		t.Fatalf("replay did not send: %v", sent)
+	}
+}
+
+func TestStreamInterceptor_BreaksAtOnce(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	var dials int
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		dials++
+		return &fakeStream{err: unavailable}, nil
+	}
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	cs, err := StreamInterceptor(r, nil)(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/svc/Watch", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Streams that break before delivering anything don't reset the
+	// backoff, so the retrier's attempts run out.
+	var m string
+	if err := cs.RecvMsg(&m); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	if dials != 4 {
+		t.Fatalf("got %d dials, want 4", dials)
+	}
+}
@@ -0,0 +1,101 @@
+package retrygrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coder/retry"
+)
+
+// Idempotency says whether a method may safely be called more than once,
+// like the idempotency_level option of protobuf methods.
+type Idempotency int
+
+const (
+	// IdempotencyUnknown means calling the method twice may have twice the
+	// effect. It is the default.
+	IdempotencyUnknown Idempotency = iota
+	// NoSideEffects means the method only reads.
+	NoSideEffects
+	// Idempotent means the method writes, but calling it again has no
+	// further effect.
+	Idempotent
+)
+
+// Unary configures UnaryInterceptor. Reads are retried more eagerly than
+// writes, as a write that seemed to fail may still have been applied.
+type Unary struct {
+	// Idempotency classifies methods by their full name, such as
+	// "/pkg.Service/Get". Methods of unknown idempotency are called once. If
+	// nil, every method is.
+	Idempotency func(method string) Idempotency
+
+	// Retrier is cloned for every call of a method with NoSideEffects. If
+	// nil, such calls are attempted up to 3 times, backing off from 100ms to
+	// 5s.
+	Retrier *retry.Retrier
+
+	// WriteRetrier is cloned for every call of an Idempotent method. If nil,
+	// such calls are retried once, backing off like reads.
+	WriteRetrier *retry.Retrier
+
+	// ShouldRetry decides whether a failed call is retried. If nil, calls
+	// failing with codes.Unavailable are.
+	ShouldRetry func(err error) bool
+}
+
+// UnaryInterceptor returns a client interceptor that retries unary calls as
+// u configures.
+func UnaryInterceptor(u Unary) grpc.UnaryClientInterceptor {
+	shouldRetry := u.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = OnCodes(codes.Unavailable)
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		r := u.retrier(method)
+		if r == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return r.Do(ctx, func(ctx context.Context) error {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err != nil && !shouldRetry(err) {
+				return retry.Abort(err)
+			}
+			return err
+		})
+	}
+}
+
+// retrier returns a fresh retrier for a call of method, or nil if it must be
+// made once.
+func (u Unary) retrier(method string) *retry.Retrier {
+	idempotency := IdempotencyUnknown
+	if u.Idempotency != nil {
+		idempotency = u.Idempotency(method)
+	}
+	switch {
+	case idempotency == NoSideEffects:
+		return u.readRetrier()
+	case idempotency != Idempotent:
+		return nil
+	case u.WriteRetrier != nil:
+		return u.WriteRetrier.Clone()
+	}
+	r := u.readRetrier()
+	if r.MaxAttempts == 0 || r.MaxAttempts > 2 {
+		r.MaxAttempts = 2
+	}
+	return r
+}
+
+func (u Unary) readRetrier() *retry.Retrier {
+	if u.Retrier != nil {
+		return u.Retrier.Clone()
+	}
+	r := retry.New(time.Millisecond*100, time.Second*5)
+	r.MaxAttempts = 3
+	return r
+}
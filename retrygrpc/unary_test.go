@@ -0,0 +1,50 @@
+package retrygrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/coder/retry"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	levels := map[string]Idempotency{
+		"/svc/Get": NoSideEffects,
+		"/svc/Put": Idempotent,
+	}
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 5
+	intercept := UnaryInterceptor(Unary{
+		Idempotency: func(method string) Idempotency { return levels[method] },
+		Retrier:     r,
+	})
+
+	for _, tc := range []struct {
+		method string
+		err    error
+		calls  int
+	}{
+		{"/svc/Get", status.Error(codes.Unavailable, "down"), 5},
+		{"/svc/Get", status.Error(codes.NotFound, "missing"), 1},
+		{"/svc/Put", status.Error(codes.Unavailable, "down"), 2},
+		{"/svc/Create", status.Error(codes.Unavailable, "down"), 1},
+	} {
+		var calls int
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			return tc.err
+		}
+		err := intercept(context.Background(), tc.method, nil, nil, nil, invoker)
+		if status.Code(err) != status.Code(tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.method, err, tc.err)
+		}
+		if calls != tc.calls {
+			t.Errorf("%s: got %d calls, want %d", tc.method, calls, tc.calls)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package retryhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/coder/retry"
+)
+
+var (
+	// ErrChanged is returned by downloads whose resource changed between
+	// attempts, as shown by its ETag or Last-Modified header.
+	ErrChanged = errors.New("retryhttp: resource changed during download")
+
+	// ErrRangeNotSupported is returned by downloads that can't be resumed
+	// because the server ignored the Range header.
+	ErrRangeNotSupported = errors.New("retryhttp: server does not support range requests")
+)
+
+// Download returns a reader of the resource at url that survives broken
+// connections by resuming with Range requests, backing off between attempts
+// according to a clone of r. Responses the Transport would retry are retried
+// too, honoring Retry-After.
+//
+// The ETag of the first response, or its Last-Modified header if it has no
+// ETag, is sent as If-Range when resuming so content can't silently change
+// mid-download. If it does, reads fail with ErrChanged.
+//
+// If client is nil, http.DefaultClient is used.
+func Download(ctx context.Context, client *http.Client, url string, r *retry.Retrier) io.ReadCloser {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	d := &download{client: client, url: url}
+	return retry.NewReader(ctx, d.openAt, r)
+}
+
+type download struct {
+	client *http.Client
+	url    string
+
+	// etag and lastModified identify the version being downloaded.
+	etag         string
+	lastModified string
+}
+
+func (d *download) openAt(ctx context.Context, offset int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, retry.Abort(err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if v := d.validator(); v != "" {
+			req.Header.Set("If-Range", v)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.check(resp, offset); err != nil {
+		discard(resp)
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// validator returns the If-Range value for resuming.
+func (d *download) validator() string {
+	if d.etag != "" {
+		return d.etag
+	}
+	return d.lastModified
+}
+
+// check reports whether resp continues the download from offset.
+func (d *download) check(resp *http.Response, offset int64) error {
+	if ShouldRetry(resp, nil) {
+		return After(resp, statusError{resp})
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if offset == 0 {
+		if resp.StatusCode != http.StatusOK {
+			return retry.Abort(statusError{resp})
+		}
+		d.etag, d.lastModified = etag, lastModified
+		return nil
+	}
+
+	if (d.etag != "" && etag != d.etag) || (d.etag == "" && d.lastModified != "" && lastModified != d.lastModified) {
+		return retry.Abort(ErrChanged)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return retry.Abort(ErrRangeNotSupported)
+	default:
+		return retry.Abort(statusError{resp})
+	}
+	if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+		return retry.Abort(ErrRangeNotSupported)
+	}
+	return nil
+}
+
+// rangeStart parses the first byte position of a Content-Range header.
+func rangeStart(v string) (int64, bool) {
+	v = strings.TrimPrefix(v, "bytes ")
+	i := strings.IndexByte(v, '-')
+	if i < 0 {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(v[:i], 10, 64)
+	return start, err == nil
+}
@@ -0,0 +1,107 @@
+package retryhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cutBody fails after limit bytes.
+type cutBody struct {
+	io.ReadCloser
+	limit int
+}
+
+func (b *cutBody) Read(p []byte) (int, error) {
+	if b.limit == 0 {
+		return 0, errors.New("connection reset")
+	}
+	if len(p) > b.limit {
+		p = p[:b.limit]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.limit -= n
+	return n, err
+}
+
+// flakyClient cuts every response body off after limit bytes.
+func flakyClient(limit int, ranges *[]string) *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*ranges = append(*ranges, req.Header.Get("Range"))
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &cutBody{ReadCloser: resp.Body, limit: limit}
+		return resp, nil
+	})}
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 5)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	var ranges []string
+	rd := Download(context.Background(), flakyClient(20, &ranges), srv.URL, newRetrier(0))
+	defer rd.Close()
+
+	got, err := io.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Fatalf("got %q, want %q", got, content)
+	}
+	want := []string{"", "bytes=20-", "bytes=40-"}
+	if strings.Join(ranges, ",") != strings.Join(want, ",") {
+		t.Fatalf("got ranges %q, want %q", ranges, want)
+	}
+}
+
+func TestDownload_Changed(t *testing.T) {
+	t.Parallel()
+
+	var version int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v`+string(rune('0'+atomic.AddInt32(&version, 1)))+`"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.Repeat("x", 50)))
+	}))
+	defer srv.Close()
+
+	var ranges []string
+	rd := Download(context.Background(), flakyClient(20, &ranges), srv.URL, newRetrier(0))
+	defer rd.Close()
+
+	if _, err := io.ReadAll(rd); !errors.Is(err, ErrChanged) {
+		t.Fatalf("got %v, want %v", err, ErrChanged)
+	}
+}
+
+func TestDownload_NoRanges(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 50))
+	}))
+	defer srv.Close()
+
+	var ranges []string
+	rd := Download(context.Background(), flakyClient(20, &ranges), srv.URL, newRetrier(0))
+	defer rd.Close()
+
+	if _, err := io.ReadAll(rd); !errors.Is(err, ErrRangeNotSupported) {
+		t.Fatalf("got %v, want %v", err, ErrRangeNotSupported)
+	}
+}
@@ -0,0 +1,58 @@
+// Package retryhttp provides helpers for retrying HTTP requests.
+package retryhttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// RetryAfter returns the delay requested by resp's Retry-After header, which
+// may be given in seconds or as an HTTP date. Dates are interpreted relative
+// to the response's Date header when present, to be immune to clock skew.
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		if secs > int64(1<<63-1)/int64(time.Second) {
+			return 1<<63 - 1, true
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	at, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	now := time.Now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+	d := at.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// After wraps err with retry.After using the delay requested by resp's
+// Retry-After header. If there is no valid header, err is returned as is.
+func After(resp *http.Response, err error) error {
+	d, ok := RetryAfter(resp)
+	if !ok {
+		return err
+	}
+	return retry.After(err, d)
+}
@@ -0,0 +1,60 @@
+package retryhttp
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Date(2023, 11, 11, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"missing", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": {"120"}}, time.Minute * 2, true},
+		{"zero", http.Header{"Retry-After": {"0"}}, 0, true},
+		{"negative", http.Header{"Retry-After": {"-5"}}, 0, false},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0, false},
+		{"huge", http.Header{"Retry-After": {"99999999999999999"}}, 1<<63 - 1, true},
+		{"date", http.Header{
+			"Retry-After": {date.Add(time.Second * 30).Format(http.TimeFormat)},
+			"Date":        {date.Format(http.TimeFormat)},
+		}, time.Second * 30, true},
+		{"past date", http.Header{
+			"Retry-After": {date.Add(-time.Hour).Format(http.TimeFormat)},
+			"Date":        {date.Format(http.TimeFormat)},
+		}, 0, true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, ok := RetryAfter(&http.Response{Header: tc.header})
+			if d != tc.want || ok != tc.ok {
+				t.Fatalf("got (%v, %v), want (%v, %v)", d, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	throttled := errors.New("429")
+	resp := &http.Response{Header: http.Header{"Retry-After": {"7"}}}
+
+	err := After(resp, throttled)
+	if !errors.Is(err, throttled) {
+		t.Fatalf("error not wrapped: %v", err)
+	}
+	if d, ok := retry.RetryAfter(err); !ok || d != time.Second*7 {
+		t.Fatalf("got (%v, %v), want (%v, true)", d, ok, time.Second*7)
+	}
+
+	if err := After(&http.Response{Header: http.Header{}}, throttled); err != throttled {
+		t.Fatalf("got %v, want %v", err, throttled)
+	}
+}
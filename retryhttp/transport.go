@@ -0,0 +1,273 @@
+package retryhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// Transport is an http.RoundTripper that retries idempotent requests on
+// connection errors and on responses that suggest trying again later.
+// Retry-After headers on such responses are honored, even beyond the
+// Retrier's Ceil; its MaxElapsed and the request's context bound the wait.
+//
+// Reads are retried more eagerly than writes, as a write that seemed to fail
+// may still have been applied. Requests that are neither reads nor known to
+// be idempotent, such as a POST without an Idempotency-Key header, are sent
+// once.
+//
+// Each attempt's context carries the deadline set by the Retrier's
+// AttemptTimeout, if any, and the request's own. When there is one, the
+// attempt also carries the time left in a TimeoutHeader header, so the
+// server can stop working on a request the client is going to give up on.
+// A TimeoutHeader set by the caller is left alone.
+//
+// Request bodies are replayed using Request.GetBody when set. Otherwise they
+// are buffered in memory up to MaxBodyBuffer; requests with larger bodies are
+// sent once and not retried.
+type Transport struct {
+	// Base makes the individual attempts. If nil, http.DefaultTransport is
+	// used.
+	Base http.RoundTripper
+
+	// Retrier is cloned for every request that only reads: GET, HEAD,
+	// OPTIONS and TRACE. If nil, they are attempted up to 3 times, backing
+	// off from 100ms to 5s. Its JoinErrors, WrapErrors and Fallback are
+	// ignored, as are WriteRetrier's.
+	Retrier *retry.Retrier
+
+	// WriteRetrier is cloned for every write that may safely be repeated:
+	// PUT and DELETE requests, and others with an Idempotency-Key or
+	// X-Idempotency-Key header. If nil, they are retried once, backing off
+	// like reads.
+	WriteRetrier *retry.Retrier
+
+	// ShouldRetry decides whether an attempt should be retried. If nil,
+	// ShouldRetry is used.
+	ShouldRetry func(resp *http.Response, err error) bool
+
+	// MaxBodyBuffer is the largest request body, in bytes, that is buffered
+	// so it can be replayed. Zero means 1 MiB; negative disables buffering.
+	MaxBodyBuffer int64
+}
+
+const defaultMaxBodyBuffer = 1 << 20
+
+// TimeoutHeader is the header in which Transport tells the server how long
+// an attempt has left, in seconds, such as "2.5".
+const TimeoutHeader = "X-Request-Timeout"
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// ShouldRetry retries connection errors, 429 Too Many Requests and 5xx
+// responses other than 501 Not Implemented. Attempts that ran out of time,
+// such as under the Retrier's AttemptTimeout, are retried too; Transport
+// stops on its own once the request's context is done.
+func ShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return resp.StatusCode >= 500
+	}
+}
+
+// statusError signals that resp should be retried.
+type statusError struct {
+	resp *http.Response
+}
+
+func (e statusError) Error() string {
+	return "retryhttp: " + e.resp.Status
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r := t.retrier(req)
+	if r == nil {
+		return base.RoundTrip(req)
+	}
+	getBody, body, err := t.replayable(req)
+	if err != nil {
+		return nil, err
+	}
+	if getBody == nil {
+		once := req.Clone(req.Context())
+		once.Body = body
+		return base.RoundTrip(once)
+	}
+	shouldRetry := t.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = ShouldRetry
+	}
+
+	var last *http.Response
+	resp, err := retry.FuncCtx[*http.Response](func(ctx context.Context) (*http.Response, error) {
+		if last != nil {
+			discard(last)
+			last = nil
+		}
+
+		body, err := getBody()
+		if err != nil {
+			return nil, retry.Abort(err)
+		}
+		attempt := req.Clone(ctx)
+		attempt.Body = body
+		if deadline, ok := ctx.Deadline(); ok && attempt.Header.Get(TimeoutHeader) == "" {
+			left := time.Until(deadline).Seconds()
+			attempt.Header.Set(TimeoutHeader, strconv.FormatFloat(left, 'f', 3, 64))
+		}
+
+		resp, err := base.RoundTrip(attempt)
+		if err != nil && req.Context().Err() != nil {
+			return nil, retry.Abort(err)
+		}
+		if !shouldRetry(resp, err) {
+			if err != nil {
+				return nil, retry.Abort(err)
+			}
+			return resp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		last = resp
+		return nil, After(resp, statusError{resp})
+	}).Do(req.Context(), r)
+
+	var serr statusError
+	if errors.As(err, &serr) {
+		// Out of attempts; the caller gets the last response as is.
+		return serr.resp, nil
+	}
+	if err != nil && last != nil {
+		discard(last)
+	}
+	return resp, err
+}
+
+// replayable returns a function producing a fresh copy of req's body for
+// every attempt. If the body can't be replayed, it returns nil and a body to
+// send once instead.
+func (t *Transport) replayable(req *http.Request) (func() (io.ReadCloser, error), io.ReadCloser, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return req.Body, nil }, nil, nil
+	}
+	if req.GetBody != nil {
+		first := true
+		return func() (io.ReadCloser, error) {
+			// The original body is good for the first attempt.
+			if first {
+				first = false
+				return req.Body, nil
+			}
+			return req.GetBody()
+		}, nil, nil
+	}
+
+	limit := t.MaxBodyBuffer
+	if limit == 0 {
+		limit = defaultMaxBodyBuffer
+	}
+	if limit < 0 {
+		return nil, req.Body, nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		_ = req.Body.Close()
+		return nil, nil, err
+	}
+	if int64(len(buf)) > limit {
+		// Too big to buffer: stitch back together what was read.
+		return nil, readCloser{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}, nil
+	}
+	_ = req.Body.Close()
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}, nil, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// retrier returns a fresh retrier for req, or nil if req must be sent once.
+func (t *Transport) retrier(req *http.Request) *retry.Retrier {
+	r := t.policy(req)
+	if r != nil {
+		// RoundTrip needs the last failure as is to hand back its response.
+		r.JoinErrors, r.WrapErrors, r.Fallback = false, false, nil
+	}
+	return r
+}
+
+func (t *Transport) policy(req *http.Request) *retry.Retrier {
+	switch {
+	case safe(req):
+		return t.readRetrier()
+	case !idempotent(req):
+		return nil
+	case t.WriteRetrier != nil:
+		return t.WriteRetrier.Clone()
+	}
+	r := t.readRetrier()
+	if r.MaxAttempts == 0 || r.MaxAttempts > 2 {
+		r.MaxAttempts = 2
+	}
+	return r
+}
+
+func (t *Transport) readRetrier() *retry.Retrier {
+	if t.Retrier != nil {
+		return t.Retrier.Clone()
+	}
+	r := retry.New(time.Millisecond*100, time.Second*5)
+	r.MaxAttempts = 3
+	return r
+}
+
+// safe reports whether req only reads.
+func safe(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// idempotent reports whether req may safely be sent more than once.
+func idempotent(req *http.Request) bool {
+	if safe(req) || req.Method == http.MethodPut || req.Method == http.MethodDelete {
+		return true
+	}
+	// Same convention as net/http.
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// discard drains and closes a response that won't be returned, so the
+// connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,355 @@
+package retryhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+func newRetrier(attempts int) *retry.Retrier {
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = attempts
+	return r
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Retrier: newRetrier(5)}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("got %v %q", resp.Status, body)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestTransport_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, "down")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Retrier: newRetrier(3)}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway || string(body) != "down" {
+		t.Fatalf("got %v %q", resp.Status, body)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestTransport_ExhaustedOptions(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, "down "+strconv.Itoa(int(n)))
+	}))
+	defer srv.Close()
+
+	// Options that change what Do gives up with don't change the response.
+	r := newRetrier(3)
+	r.JoinErrors = true
+	r.WrapErrors = true
+	r.Fallback = func(ctx context.Context, err error) error { return nil }
+	client := &http.Client{Transport: &Transport{Retrier: r}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway || string(body) != "down 3" {
+		t.Fatalf("got %v %q", resp.Status, body)
+	}
+}
+
+func TestTransport_NotRetried(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Retrier: newRetrier(3)}}
+
+	// Not a retryable status.
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Not idempotent.
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
+
+func TestTransport_ConnectionError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	var calls int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := &http.Client{Transport: &Transport{Base: base, Retrier: newRetrier(3)}}
+	if _, err := client.Get(url); err == nil {
+		t.Fatalf("expected error")
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport_Body(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls  int32
+		bodies = make(chan string, 10)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name    string
+		getBody bool
+		limit   int64
+		want    []string
+	}{
+		{"GetBody", true, 0, []string{"payload", "payload"}},
+		{"buffered", false, 0, []string{"payload", "payload"}},
+		{"too large", false, 3, []string{"payload"}},
+		{"buffering disabled", false, -1, []string{"payload"}},
+	} {
+		atomic.StoreInt32(&calls, 0)
+
+		req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tc.getBody {
+			req.GetBody = nil
+			req.Body = io.NopCloser(req.Body)
+		}
+
+		client := &http.Client{Transport: &Transport{Retrier: newRetrier(3), MaxBodyBuffer: tc.limit}}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+
+		for i, want := range tc.want {
+			if got := <-bodies; got != want {
+				t.Errorf("%s: attempt %d: got body %q, want %q", tc.name, i, got, want)
+			}
+		}
+		if len(bodies) != 0 {
+			t.Errorf("%s: unexpected extra attempts", tc.name)
+			for len(bodies) > 0 {
+				<-bodies
+			}
+		}
+	}
+}
+
+func TestTransport_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	r := newRetrier(3)
+	r.AttemptTimeout = time.Millisecond * 50
+	client := &http.Client{Transport: &Transport{Retrier: r}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("got %v after %d calls, want 200 after 2", resp.Status, atomic.LoadInt32(&calls))
+	}
+}
+
+func TestTransport_RequestDeadline(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Retrier: newRetrier(3)}, Timeout: time.Millisecond * 50}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("got %d calls, want 1", got)
+	}
+}
+
+func TestTransport_Writes(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	newRequest := func(method string, key bool) *http.Request {
+		req, err := http.NewRequest(method, srv.URL, strings.NewReader("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key {
+			req.Header.Set("Idempotency-Key", "1")
+		}
+		return req
+	}
+	for _, tc := range []struct {
+		name  string
+		t     *Transport
+		req   *http.Request
+		calls int32
+	}{
+		{"read", &Transport{Retrier: newRetrier(5)}, newRequest(http.MethodGet, false), 5},
+		{"put", &Transport{Retrier: newRetrier(5)}, newRequest(http.MethodPut, false), 2},
+		{"keyed post", &Transport{Retrier: newRetrier(5)}, newRequest(http.MethodPost, true), 2},
+		{"post", &Transport{Retrier: newRetrier(5)}, newRequest(http.MethodPost, false), 1},
+		{"write retrier", &Transport{Retrier: newRetrier(5), WriteRetrier: newRetrier(3)}, newRequest(http.MethodPut, false), 3},
+	} {
+		atomic.StoreInt32(&calls, 0)
+		resp, err := tc.t.RoundTrip(tc.req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if got := atomic.LoadInt32(&calls); got != tc.calls {
+			t.Errorf("%s: got %d calls, want %d", tc.name, got, tc.calls)
+		}
+	}
+}
+
+func TestTransport_TimeoutHeader(t *testing.T) {
+	t.Parallel()
+
+	headers := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get(TimeoutHeader)
+	}))
+	defer srv.Close()
+
+	r := newRetrier(3)
+	r.AttemptTimeout = time.Second * 2
+	client := &http.Client{Transport: &Transport{Retrier: r}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	left, err := strconv.ParseFloat(<-headers, 64)
+	if err != nil || left <= 1 || left > 2 {
+		t.Fatalf("got %v seconds left, err %v", left, err)
+	}
+
+	// Without a deadline, there's no header.
+	resp, err = (&http.Client{Transport: &Transport{Retrier: newRetrier(3)}}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if h := <-headers; h != "" {
+		t.Fatalf("got header %q without a deadline", h)
+	}
+}
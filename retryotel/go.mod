@@ -0,0 +1,19 @@
+module github.com/coder/retry/retryotel
+
+go 1.20
+
+require (
+	github.com/coder/retry v1.5.2-0.20261016015639-1bd2fdbe9880
+	go.opentelemetry.io/otel v1.19.0
+	go.opentelemetry.io/otel/sdk v1.19.0
+	go.opentelemetry.io/otel/trace v1.19.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.19.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+)
+
+replace github.com/coder/retry => ../
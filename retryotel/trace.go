@@ -0,0 +1,51 @@
+// Package retryotel records retries in OpenTelemetry traces. It is a
+// separate module so that users of retry don't depend on OpenTelemetry.
+package retryotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/coder/retry"
+)
+
+// EventName is the name of the span events recorded for attempts.
+const EventName = "retry.attempt"
+
+// Attribute keys of the span events recorded for attempts.
+const (
+	AttemptKey = attribute.Key("retry.attempt")
+	DelayKey   = attribute.Key("retry.delay")
+	ErrorKey   = attribute.Key("retry.error")
+)
+
+// Do calls fn under a clone of r like Retrier.Do, adding an event to the
+// span in ctx for each attempt, with its number, the delay before it and any
+// error.
+func Do(ctx context.Context, r *retry.Retrier, fn func(ctx context.Context) error) error {
+	span := trace.SpanFromContext(ctx)
+
+	r = r.Clone()
+	var delay time.Duration
+	r.AddObserver(func(attempt int, err error, next time.Duration) {
+		delay = next
+	})
+
+	var attempt int
+	return r.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		err := fn(ctx)
+		attrs := []attribute.KeyValue{
+			AttemptKey.Int(attempt),
+			DelayKey.String(delay.String()),
+		}
+		if err != nil {
+			attrs = append(attrs, ErrorKey.String(err.Error()))
+		}
+		span.AddEvent(EventName, trace.WithAttributes(attrs...))
+		return err
+	})
+}
@@ -0,0 +1,62 @@
+package retryotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/coder/retry"
+)
+
+func TestDo(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+	fail := errors.New("fail")
+	var calls int
+	err := Do(ctx, r, func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return fail
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	events := rec.Ended()[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	want := []map[string]string{
+		{"retry.attempt": "1", "retry.delay": "0s", "retry.error": "fail"},
+		{"retry.attempt": "2", "retry.delay": "1ms"},
+	}
+	for i, e := range events {
+		if e.Name != EventName {
+			t.Errorf("event %d: got name %q", i, e.Name)
+		}
+		got := make(map[string]string)
+		for _, a := range e.Attributes {
+			got[string(a.Key)] = a.Value.Emit()
+		}
+		if len(got) != len(want[i]) {
+			t.Errorf("event %d: got %v, want %v", i, got, want[i])
+			continue
+		}
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("event %d: got %v, want %v", i, got, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package retryprom exports Prometheus metrics about retries. It is a
+// separate module so that users of retry don't depend on Prometheus.
+package retryprom
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/coder/retry"
+)
+
+// Metrics counts attempts, retries and give-ups, records backoff delays, and
+// tracks the delays being waited out right now, labelled by the name of the
+// retry policy. The last tells calls stuck waiting on a broken dependency
+// apart from ones that are busy.
+type Metrics struct {
+	attempts *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	giveUps  *prometheus.CounterVec
+	backoff  *prometheus.HistogramVec
+	sleeping *prometheus.GaugeVec
+}
+
+// NewMetrics creates metrics and registers them on reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_attempts_total",
+			Help: "Attempts made, including first attempts.",
+		}, []string{"policy"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_retries_total",
+			Help: "Failed attempts that were retried.",
+		}, []string{"policy"}),
+		giveUps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_give_ups_total",
+			Help: "Operations that failed after all their attempts.",
+		}, []string{"policy"}),
+		backoff: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "retry_backoff_seconds",
+			Help:    "Delays before retries.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}, []string{"policy"}),
+		sleeping: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "retry_sleeping_seconds",
+			Help: "Total delay of the retries currently backing off.",
+		}, []string{"policy"}),
+	}
+	for _, c := range []prometheus.Collector{m.attempts, m.retries, m.giveUps, m.backoff, m.sleeping} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Do calls fn under a clone of r like Retrier.Do, recording metrics under
+// policy.
+func (m *Metrics) Do(ctx context.Context, policy string, r *retry.Retrier, fn func(ctx context.Context) error) error {
+	attempts := m.attempts.WithLabelValues(policy)
+	retries := m.retries.WithLabelValues(policy)
+	backoff := m.backoff.WithLabelValues(policy)
+	sleeping := m.sleeping.WithLabelValues(policy)
+
+	// The delay being slept, counted until the next attempt or until Do
+	// gives up during it.
+	var asleep float64
+	wake := func() {
+		sleeping.Sub(asleep)
+		asleep = 0
+	}
+
+	r = r.Clone()
+	r.AddObserver(func(attempt int, err error, next time.Duration) {
+		retries.Inc()
+		backoff.Observe(next.Seconds())
+		asleep = next.Seconds()
+		sleeping.Add(asleep)
+	})
+
+	err := r.Do(ctx, func(ctx context.Context) error {
+		wake()
+		attempts.Inc()
+		return fn(ctx)
+	})
+	wake()
+	if err != nil {
+		m.giveUps.WithLabelValues(policy).Inc()
+	}
+	return err
+}
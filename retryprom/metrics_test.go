@@ -0,0 +1,88 @@
+package retryprom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/coder/retry"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := retry.New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	var notified int
+	r.Notify = func(int, error, time.Duration) { notified++ }
+
+	ctx := context.Background()
+	fail := errors.New("fail")
+	if err := m.Do(ctx, "flaky", r, func(ctx context.Context) error { return fail }); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if err := m.Do(ctx, "flaky", r, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		c    *prometheus.CounterVec
+		want float64
+	}{
+		{"attempts", m.attempts, 4},
+		{"retries", m.retries, 2},
+		{"give-ups", m.giveUps, 1},
+	} {
+		if got := testutil.ToFloat64(tc.c.WithLabelValues("flaky")); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := testutil.CollectAndCount(m.backoff); got != 1 {
+		t.Errorf("got %d backoff series, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.sleeping.WithLabelValues("flaky")); got != 0 {
+		t.Errorf("got %v seconds sleeping, want 0", got)
+	}
+	if notified != 2 {
+		t.Errorf("existing Notify called %d times, want 2", notified)
+	}
+
+	if _, err := NewMetrics(reg); err == nil {
+		t.Fatal("registered the same metrics twice")
+	}
+}
+
+func TestMetrics_Sleeping(t *testing.T) {
+	m, err := NewMetrics(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleeping := m.sleeping.WithLabelValues("down")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Do(ctx, "down", retry.New(time.Hour, time.Hour), func(ctx context.Context) error {
+			return errors.New("fail")
+		})
+	}()
+
+	// The hour-long backoff shows until Do gives up on it.
+	for testutil.ToFloat64(sleeping) != 3600 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := testutil.ToFloat64(sleeping); got != 0 {
+		t.Fatalf("got %v seconds sleeping after Do returned, want 0", got)
+	}
+}
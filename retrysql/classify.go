@@ -0,0 +1,109 @@
+package retrysql
+
+import (
+	"reflect"
+	"strings"
+)
+
+// IsTransient reports whether err is a transient database error that is
+// worth retrying the transaction for: a Postgres serialization failure or
+// deadlock, a MySQL deadlock or lock wait timeout, or SQLITE_BUSY.
+//
+// It is suitable for deciding whether to retry with retry.Do:
+//
+//	if !retrysql.IsTransient(err) {
+//		return retry.Abort(err)
+//	}
+func IsTransient(err error) bool {
+	return IsSerializationFailure(err) || IsMySQLTransient(err) || IsSQLiteBusy(err)
+}
+
+// IsMySQLTransient reports whether err is a MySQL deadlock (1213) or lock wait
+// timeout (1205). It recognizes the errors of github.com/go-sql-driver/mysql
+// without depending on it.
+func IsMySQLTransient(err error) bool {
+	return walk(err, func(err error) bool {
+		v, ok := driverError(err, "mysql")
+		if !ok {
+			return false
+		}
+		n := v.FieldByName("Number")
+		if n.Kind() != reflect.Uint16 {
+			return false
+		}
+		switch n.Uint() {
+		case 1205, 1213:
+			return true
+		}
+		return false
+	})
+}
+
+// IsSQLiteBusy reports whether err is SQLITE_BUSY, including its extended
+// codes. It recognizes the errors of github.com/mattn/go-sqlite3 and
+// modernc.org/sqlite without depending on them.
+func IsSQLiteBusy(err error) bool {
+	const sqliteBusy = 5
+	return walk(err, func(err error) bool {
+		if coder, ok := err.(interface{ Code() int }); ok && isDriverError(err, "sqlite") {
+			return coder.Code()&0xff == sqliteBusy
+		}
+		v, ok := driverError(err, "sqlite")
+		if !ok {
+			return false
+		}
+		c := v.FieldByName("Code")
+		switch c.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			return c.Int()&0xff == sqliteBusy
+		}
+		return false
+	})
+}
+
+// driverError returns the struct underlying err if its type comes from a
+// package whose import path contains pkg.
+func driverError(err error, pkg string) (reflect.Value, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || !strings.Contains(v.Type().PkgPath(), pkg) {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+func isDriverError(err error, pkg string) bool {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.Contains(t.PkgPath(), pkg)
+}
+
+// walk reports whether fn holds for any error in err's tree.
+func walk(err error, fn func(error) bool) bool {
+	for err != nil {
+		if fn(err) {
+			return true
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				if walk(err, fn) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
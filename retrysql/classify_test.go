@@ -0,0 +1,44 @@
+package retrysql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coder/retry/retrysql/internal/mysql"
+	"github.com/coder/retry/retrysql/internal/sqlite"
+)
+
+// codeError has a Code method but doesn't come from a SQLite driver.
+type codeError int
+
+func (e codeError) Error() string { return "code" }
+func (e codeError) Code() int     { return int(e) }
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("boom"), false},
+		{"postgres serialization", stateError("40001"), true},
+		{"postgres deadlock", stateError("40P01"), true},
+		{"postgres unique violation", stateError("23505"), false},
+		{"mysql deadlock", &mysql.MySQLError{Number: 1213}, true},
+		{"mysql lock wait", &mysql.MySQLError{Number: 1205}, true},
+		{"mysql duplicate", &mysql.MySQLError{Number: 1062}, false},
+		{"sqlite busy", sqlite.Error{Code: 5}, true},
+		{"sqlite constraint", sqlite.Error{Code: 19}, false},
+		{"modernc busy snapshot", sqlite.NewCodeError(517), true},
+		{"modernc readonly", sqlite.NewCodeError(8), false},
+		{"foreign code", codeError(5), false},
+		{"wrapped", fmt.Errorf("exec: %w", &mysql.MySQLError{Number: 1213}), true},
+		{"joined", errors.Join(errors.New("a"), sqlite.Error{Code: 5}), true},
+	} {
+		if got := IsTransient(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Package mysql mirrors the error type of github.com/go-sql-driver/mysql for
+// testing.
+package mysql
+
+import "fmt"
+
+// MySQLError has the shape of the driver's error type.
+type MySQLError struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (me *MySQLError) Error() string {
+	return fmt.Sprintf("Error %d (%s): %s", me.Number, me.SQLState[:], me.Message)
+}
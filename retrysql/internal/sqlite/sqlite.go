@@ -0,0 +1,37 @@
+// Package sqlite mirrors the error types of the common SQLite drivers for
+// testing.
+package sqlite
+
+import "fmt"
+
+// ErrNo is a SQLite result code.
+type ErrNo int
+
+// Error has the shape of github.com/mattn/go-sqlite3's error type.
+type Error struct {
+	Code         ErrNo
+	ExtendedCode ErrNo
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("sqlite error %d", e.Code)
+}
+
+// CodeError has the shape of modernc.org/sqlite's error type.
+type CodeError struct {
+	code int
+}
+
+// NewCodeError creates a CodeError with the given extended result code.
+func NewCodeError(code int) *CodeError {
+	return &CodeError{code: code}
+}
+
+func (e *CodeError) Error() string {
+	return fmt.Sprintf("sqlite error %d", e.code)
+}
+
+// Code returns the extended result code.
+func (e *CodeError) Code() int {
+	return e.code
+}
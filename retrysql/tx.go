@@ -0,0 +1,60 @@
+// Package retrysql provides helpers for retrying database transactions.
+package retrysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/coder/retry"
+)
+
+// Tx runs fn in a transaction and commits it. If the transaction fails with
+// an error recognized by IsTransient, it is rolled back and the whole
+// transaction, not just the failed statement, is retried according to a
+// clone of r. Other errors are returned immediately.
+//
+// fn may be called several times, so it must not have effects outside of tx.
+func Tx(ctx context.Context, db *sql.DB, r *retry.Retrier, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	_, err := retry.FuncCtx[struct{}](func(ctx context.Context) (struct{}, error) {
+		err := runTx(ctx, db, fn)
+		if err != nil && !IsTransient(err) {
+			return struct{}{}, retry.Abort(err)
+		}
+		return struct{}{}, err
+	}).Do(ctx, r.Clone())
+	return err
+}
+
+// runTx makes a single attempt at the transaction.
+func runTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsSerializationFailure reports whether err is a serialization failure
+// (SQLSTATE 40001) or deadlock (SQLSTATE 40P01). It recognizes errors with
+// a SQLState method, as provided by the common Postgres drivers.
+func IsSerializationFailure(err error) bool {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return false
+	}
+	switch state.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package retrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// stateError is an error with a SQLSTATE, like those of Postgres drivers.
+type stateError string
+
+func (e stateError) Error() string    { return "sqlstate " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+// fakeDB is a driver that only supports transactions, counting how they end.
+type fakeDB struct {
+	commits, rollbacks int
+	commitErr          error
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unsupported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx(c), nil }
+
+type fakeTx struct{ db *fakeDB }
+
+func (t fakeTx) Commit() error {
+	if err := t.db.commitErr; err != nil {
+		t.db.commitErr = nil
+		return err
+	}
+	t.db.commits++
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.db.rollbacks++
+	return nil
+}
+
+func newRetrier() *retry.Retrier {
+	return retry.New(time.Millisecond, time.Millisecond)
+}
+
+func TestTx(t *testing.T) {
+	fdb := &fakeDB{}
+	db := sql.OpenDB(fdb)
+	defer db.Close()
+
+	var calls int
+	err := Tx(context.Background(), db, newRetrier(), func(ctx context.Context, tx *sql.Tx) error {
+		calls++
+		switch calls {
+		case 1:
+			return stateError("40001")
+		case 2:
+			return fmt.Errorf("update: %w", stateError("40P01"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 || fdb.rollbacks != 2 || fdb.commits != 1 {
+		t.Fatalf("got %d calls, %d rollbacks, %d commits", calls, fdb.rollbacks, fdb.commits)
+	}
+}
+
+func TestTx_CommitFailure(t *testing.T) {
+	fdb := &fakeDB{commitErr: stateError("40001")}
+	db := sql.OpenDB(fdb)
+	defer db.Close()
+
+	var calls int
+	err := Tx(context.Background(), db, newRetrier(), func(ctx context.Context, tx *sql.Tx) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 || fdb.commits != 1 {
+		t.Fatalf("got %d calls, %d commits", calls, fdb.commits)
+	}
+}
+
+func TestTx_OtherError(t *testing.T) {
+	fdb := &fakeDB{}
+	db := sql.OpenDB(fdb)
+	defer db.Close()
+
+	fail := stateError("23505")
+	var calls int
+	err := Tx(context.Background(), db, newRetrier(), func(ctx context.Context, tx *sql.Tx) error {
+		calls++
+		return fail
+	})
+	if err != fail {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 1 || fdb.rollbacks != 1 {
+		t.Fatalf("got %d calls, %d rollbacks", calls, fdb.rollbacks)
+	}
+}
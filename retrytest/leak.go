@@ -0,0 +1,84 @@
+package retrytest
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// VerifyNone fails the test if any goroutine is still inside the retry
+// package, e.g. a retry loop left sleeping in Wait after the test finished
+// with it. Like goleak, it keeps checking for a short while so loops have a
+// chance to observe cancellation before being reported. It is typically
+// deferred at the top of a test:
+//
+//	defer retrytest.VerifyNone(t)
+//
+// Goroutines are found by inspecting stacks, so loops from tests running in
+// parallel are reported too.
+func VerifyNone(t testing.TB) {
+	t.Helper()
+	verifyNone(t, time.Second)
+}
+
+func verifyNone(t testing.TB, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		leaks := retryGoroutines()
+		if len(leaks) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("found %d leaked retry loop(s):\n\n%s", len(leaks), strings.Join(leaks, "\n\n"))
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+const retryPkg = "github.com/coder/retry."
+
+// retryGoroutines returns the stacks of all other goroutines that have a
+// frame in the retry package.
+func retryGoroutines() []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	// The first stack is always the calling goroutine.
+	stacks := bytes.Split(buf, []byte("\n\n"))[1:]
+
+	var leaks []string
+	for _, stack := range stacks {
+		s := string(stack)
+		if inRetry(s) {
+			leaks = append(leaks, s)
+		}
+	}
+	return leaks
+}
+
+func inRetry(stack string) bool {
+	for _, line := range strings.Split(stack, "\n") {
+		if !strings.HasPrefix(line, retryPkg) {
+			continue
+		}
+		fn := strings.TrimPrefix(line, retryPkg)
+		// Tests of the retry package itself aren't retry loops.
+		if strings.HasPrefix(fn, "Test") || strings.HasPrefix(fn, "Benchmark") {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package retrytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// recordTB captures failures instead of failing the test.
+type recordTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordTB) Helper() {}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.TB.Logf(format, args...)
+}
+
+func TestVerifyNone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r := retry.New(time.Hour, time.Hour)
+		for r.Wait(ctx) {
+		}
+	}()
+
+	// Wait for the loop to start sleeping.
+	for len(retryGoroutines()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	tb := &recordTB{TB: t}
+	verifyNone(tb, time.Millisecond*100)
+	if !tb.failed {
+		t.Fatalf("leaked retry loop not reported")
+	}
+
+	cancel()
+	<-done
+
+	tb = &recordTB{TB: t}
+	verifyNone(tb, time.Second)
+	if tb.failed {
+		t.Fatalf("finished retry loop reported as leaked")
+	}
+}
+
+func TestInRetry(t *testing.T) {
+	for _, tc := range []struct {
+		stack string
+		want  bool
+	}{
+		{"goroutine 1 [select]:\n" + retryPkg + "(*Retrier).Wait(...)\n\t/x/retrier.go:10", true},
+		{"goroutine 1 [running]:\n" + retryPkg + "TestFoo(...)\n\t/x/retrier_test.go:10", false},
+		{"goroutine 1 [running]:\ngithub.com/coder/retry/retrytest.TestFoo(...)", false},
+	} {
+		if got := inRetry(tc.stack); got != tc.want {
+			t.Errorf("%q: got %v, want %v", tc.stack, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package retrytest provides utilities for testing code that uses retry.
+package retrytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+// Clock is a fake retry.Clock whose time only moves when Advance is called.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+var _ retry.Clock = (*Clock)(nil)
+
+// NewClock creates a fake clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the fake time once the clock has been
+// advanced by at least d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{deadline: c.now.Add(d), c: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing any waiters that fall due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of pending calls to After.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n calls to After are pending.
+func (c *Clock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Recorder is a retry.Clock that never blocks. It records every delay
+// requested of it and advances its own time accordingly.
+type Recorder struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+var _ retry.Clock = (*Recorder)(nil)
+
+// NewRecorder installs a Recorder as the clock of r and returns it.
+func NewRecorder(r *retry.Retrier) *Recorder {
+	rec := &Recorder{now: time.Unix(0, 0)}
+	r.Clock = rec
+	return rec
+}
+
+// Now returns the recorder's time, which is the sum of all recorded delays.
+func (r *Recorder) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+// After records d and returns a channel that is already ready.
+func (r *Recorder) After(d time.Duration) <-chan time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.delays = append(r.delays, d)
+	r.now = r.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- r.now
+	return ch
+}
+
+// Delays returns the delays recorded so far.
+func (r *Recorder) Delays() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration(nil), r.delays...)
+}
+
+// AssertDelays calls r.Wait once per element of want and fails the test if
+// the delays r sleeps for don't match want exactly. Waits don't actually
+// sleep. r's Clock is restored afterwards, but its other state is not.
+//
+// Since Jitter makes delays unpredictable, it should be zero, unless r.Rand
+// is seeded with a known value.
+func AssertDelays(t testing.TB, r *retry.Retrier, want []time.Duration) {
+	t.Helper()
+
+	clock := r.Clock
+	defer func() { r.Clock = clock }()
+
+	rec := NewRecorder(r)
+	ctx := context.Background()
+	for range want {
+		if !r.Wait(ctx) {
+			t.Fatalf("Wait returned false after %v", rec.Delays())
+		}
+	}
+
+	got := rec.Delays()
+	if len(got) != len(want) {
+		t.Fatalf("got %d delays %v, want %d delays %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("delay %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package retrytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coder/retry"
+)
+
+func TestAssertDelays(t *testing.T) {
+	r := retry.New(time.Second, time.Second*10)
+	r.Rate = 2
+
+	AssertDelays(t, r, []time.Duration{
+		0,
+		time.Second * 2,
+		time.Second * 4,
+		time.Second * 8,
+		time.Second * 10,
+		time.Second * 10,
+	})
+	if r.Clock != nil {
+		t.Fatalf("clock not restored")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := retry.New(time.Second, time.Second)
+	rec := NewRecorder(r)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		r.Wait(ctx)
+	}
+
+	if got := rec.Now().Sub(time.Unix(0, 0)); got != time.Second*2 {
+		t.Fatalf("recorder time: got %v, want %v", got, time.Second*2)
+	}
+	if got := len(rec.Delays()); got != 3 {
+		t.Fatalf("got %d delays, want 3", got)
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewClock(start)
+
+	r := retry.New(time.Minute, time.Minute)
+	r.Clock = c
+
+	ctx := context.Background()
+	// The first Wait is immediate.
+	if !r.Wait(ctx) {
+		t.Fatalf("attempt not allowed")
+	}
+
+	done := make(chan bool)
+	go func() {
+		done <- r.Wait(ctx)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second * 59)
+	select {
+	case <-done:
+		t.Fatalf("Wait returned before delay elapsed")
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	c.Advance(time.Second)
+	if !<-done {
+		t.Fatalf("attempt not allowed")
+	}
+	if got := c.Now().Sub(start); got != time.Minute {
+		t.Fatalf("clock time: got %v, want %v", got, time.Minute)
+	}
+}
@@ -0,0 +1,270 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSchedulerClosed is returned for jobs submitted to a closed Scheduler.
+var ErrSchedulerClosed = errors.New("retry: scheduler closed")
+
+// JobState is where a scheduled job is in its life.
+type JobState int
+
+const (
+	// JobWaiting means the job is waiting for its next attempt.
+	JobWaiting JobState = iota
+	// JobRunning means an attempt is in progress.
+	JobRunning
+	// JobSucceeded means an attempt succeeded.
+	JobSucceeded
+	// JobFailed means the job gave up after an error.
+	JobFailed
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobWaiting:
+		return "waiting"
+	case JobRunning:
+		return "running"
+	case JobSucceeded:
+		return "succeeded"
+	case JobFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// JobStatus is a snapshot of a job's progress.
+type JobStatus struct {
+	State JobState
+	// Attempts is the number of attempts started.
+	Attempts int
+	// Err is the error from the most recent failed attempt, or why the job
+	// gave up if it never ran.
+	Err error
+}
+
+// JobAttempt records one attempt at a job.
+type JobAttempt struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Job is a job submitted to a Scheduler.
+type Job struct {
+	// ID identifies the job within its scheduler.
+	ID uint64
+
+	// Key identifies the job in the scheduler's Store, if it has one.
+	Key string
+
+	mu      sync.Mutex
+	status  JobStatus
+	history []JobAttempt
+	done    chan struct{}
+}
+
+// Status returns the job's current status.
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// History returns the job's attempts so far, oldest first.
+func (j *Job) History() []JobAttempt {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JobAttempt(nil), j.history...)
+}
+
+// Done returns a channel that is closed when the job has finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the job to finish, returning its final error, or for ctx
+// to be done, returning ctx's error.
+func (j *Job) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		return j.Status().Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *Job) update(fn func(s *JobStatus)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.status)
+}
+
+// Scheduler retries jobs in the background, each with its own Retrier,
+// running at most a fixed number of attempts at once. Jobs don't hold a
+// worker while they back off.
+type Scheduler struct {
+	// DeadLetter, if set, is called with every job that fails, its final
+	// error and its attempts, so the work can be persisted or alerted on
+	// rather than dropped. Jobs stopped by Close aren't dead letters, as
+	// they can be resubmitted. It is called before the job is marked done, from
+	// the job's own goroutine, and must be set before any job is submitted.
+	DeadLetter func(j *Job, err error, history []JobAttempt)
+
+	// Store, if set, keeps the backoff position of jobs submitted with
+	// SubmitKey, so that resubmitting them after a restart resumes their
+	// backoff. Entries are deleted once jobs finish, unless they were
+	// stopped by Close. Store errors don't
+	// fail jobs; at worst a job's backoff starts afresh. It must be set
+	// before any job is submitted.
+	Store Store
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	nextID uint64
+	jobs   map[uint64]*Job
+	closed bool
+}
+
+// NewScheduler creates a scheduler that runs up to workers attempts at once.
+func NewScheduler(workers int) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, workers),
+		jobs:   make(map[uint64]*Job),
+	}
+}
+
+// Submit schedules fn to be retried under a clone of r until it succeeds,
+// r gives up, fn returns an error that is not Retryable or is wrapped with
+// Abort, or the scheduler is closed.
+func (s *Scheduler) Submit(fn func(ctx context.Context) error, r *Retrier) *Job {
+	return s.SubmitKey("", fn, r)
+}
+
+// SubmitKey is like Submit, but persists the job's backoff position in the
+// scheduler's Store under key, resuming from any position already there.
+func (s *Scheduler) SubmitKey(key string, fn func(ctx context.Context) error, r *Retrier) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	j := &Job{ID: s.nextID, Key: key, done: make(chan struct{})}
+	if s.closed {
+		j.status = JobStatus{State: JobFailed, Err: ErrSchedulerClosed}
+		close(j.done)
+		return j
+	}
+	s.jobs[j.ID] = j
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(j, fn, r.Clone())
+	}()
+	return j
+}
+
+func (s *Scheduler) run(j *Job, fn func(ctx context.Context) error, r *Retrier) {
+	store := s.Store
+	if j.Key == "" {
+		store = nil
+	}
+	if store != nil {
+		if st, ok, err := store.Get(s.ctx, j.Key); err == nil && ok {
+			r.SetState(st)
+		}
+	}
+
+	err := r.resume(s.ctx, func(ctx context.Context) error {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-s.sem }()
+
+		j.update(func(s *JobStatus) {
+			s.State = JobRunning
+			s.Attempts++
+		})
+		start := time.Now()
+		err := fn(ctx)
+		dur := time.Since(start)
+		j.mu.Lock()
+		j.status.State = JobWaiting
+		j.status.Err = err
+		j.history = append(j.history, JobAttempt{Start: start, Duration: dur, Err: err})
+		j.mu.Unlock()
+		if err != nil && store != nil {
+			// ctx may have been cancelled by Close, which is exactly when
+			// the state matters.
+			_ = store.Put(context.Background(), j.Key, r.State())
+		}
+		return err
+	})
+
+	j.update(func(s *JobStatus) {
+		if err == nil {
+			s.State = JobSucceeded
+			s.Err = nil
+			return
+		}
+		s.State = JobFailed
+		s.Err = err
+	})
+	// A job stopped by Close is left in the store to be resumed.
+	if store != nil && s.ctx.Err() == nil {
+		_ = store.Delete(s.ctx, j.Key)
+	}
+	if err != nil && s.DeadLetter != nil && s.ctx.Err() == nil {
+		s.DeadLetter(j, err, j.History())
+	}
+	// Finished jobs are only kept by whoever holds them, so a long-lived
+	// scheduler doesn't grow without bound.
+	s.mu.Lock()
+	delete(s.jobs, j.ID)
+	s.mu.Unlock()
+	close(j.done)
+}
+
+// Job returns the unfinished job with the given ID, or nil if there is none.
+func (s *Scheduler) Job(id uint64) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// Jobs returns the jobs that haven't finished yet. Finished jobs are
+// forgotten by the scheduler; keep the Job returned by Submit to inspect them.
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// Close stops all jobs and waits for them to finish. Jobs submitted after
+// Close fail with ErrSchedulerClosed.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+}
@@ -0,0 +1,243 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	s := NewScheduler(2)
+	defer s.Close()
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+
+	release := make(chan struct{})
+	blocked := s.Submit(func(ctx context.Context) error {
+		<-release
+		return nil
+	}, r)
+
+	var calls int32
+	ok := s.Submit(func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return errors.New("fail")
+		}
+		return nil
+	}, r)
+
+	fail := errors.New("fail")
+	bad := s.Submit(func(ctx context.Context) error {
+		return fail
+	}, r)
+
+	ctx := context.Background()
+	if err := ok.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := ok.Status(); st.State != JobSucceeded || st.Attempts != 2 {
+		t.Fatalf("got status %+v", st)
+	}
+
+	if err := bad.Wait(ctx); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if st := bad.Status(); st.State != JobFailed || st.Attempts != 3 {
+		t.Fatalf("got status %+v", st)
+	}
+
+	// Only unfinished jobs are kept.
+	if s.Job(ok.ID) != nil || s.Job(blocked.ID) != blocked || len(s.Jobs()) != 1 {
+		t.Fatal("scheduler kept finished jobs or lost unfinished ones")
+	}
+	close(release)
+	if err := blocked.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Jobs()) != 0 {
+		t.Fatal("scheduler kept a finished job")
+	}
+}
+
+func TestScheduler_Workers(t *testing.T) {
+	t.Parallel()
+
+	s := NewScheduler(1)
+	defer s.Close()
+
+	var running, max int32
+	r := New(time.Millisecond, time.Millisecond)
+	var jobs []*Job
+	for i := 0; i < 5; i++ {
+		jobs = append(jobs, s.Submit(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			if n > atomic.LoadInt32(&max) {
+				atomic.StoreInt32(&max, n)
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		}, r))
+	}
+	for _, j := range jobs {
+		if err := j.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if max != 1 {
+		t.Fatalf("ran %d attempts at once, want 1", max)
+	}
+}
+
+func TestScheduler_Close(t *testing.T) {
+	t.Parallel()
+
+	s := NewScheduler(1)
+	j := s.Submit(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, New(time.Hour, time.Hour))
+	s.Close()
+
+	select {
+	case <-j.Done():
+	default:
+		t.Fatal("Close returned before the job finished")
+	}
+	if st := j.Status(); st.State != JobFailed {
+		t.Fatalf("got state %v, want %v", st.State, JobFailed)
+	}
+
+	late := s.Submit(func(ctx context.Context) error { return nil }, New(time.Hour, time.Hour))
+	if err := late.Wait(context.Background()); !errors.Is(err, ErrSchedulerClosed) {
+		t.Fatalf("got %v, want %v", err, ErrSchedulerClosed)
+	}
+}
+
+func TestScheduler_DeadLetter(t *testing.T) {
+	t.Parallel()
+
+	type letter struct {
+		job     *Job
+		err     error
+		history []JobAttempt
+	}
+	letters := make(chan letter, 1)
+
+	s := NewScheduler(1)
+	defer s.Close()
+	s.DeadLetter = func(j *Job, err error, history []JobAttempt) {
+		letters <- letter{j, err, history}
+	}
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+	fail := errors.New("fail")
+	j := s.Submit(func(ctx context.Context) error { return fail }, r)
+	if err := j.Wait(context.Background()); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+
+	// The job is only done once the dead letter has been handled.
+	select {
+	case l := <-letters:
+		if l.job != j || !errors.Is(l.err, fail) {
+			t.Fatalf("got dead letter %+v", l)
+		}
+		if len(l.history) != 2 || !errors.Is(l.history[1].Err, fail) {
+			t.Fatalf("got history %+v", l.history)
+		}
+	default:
+		t.Fatal("no dead letter")
+	}
+
+	ok := s.Submit(func(ctx context.Context) error { return nil }, r)
+	if err := ok.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 0 {
+		t.Fatal("dead letter for a successful job")
+	}
+}
+
+func TestScheduler_DeadLetterClose(t *testing.T) {
+	t.Parallel()
+
+	var letters int32
+	s := NewScheduler(1)
+	s.DeadLetter = func(j *Job, err error, history []JobAttempt) {
+		atomic.AddInt32(&letters, 1)
+	}
+
+	started := make(chan struct{})
+	j := s.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return errors.New("transient")
+	}, New(time.Hour, time.Hour))
+	<-started
+	s.Close()
+
+	if j.Status().State != JobFailed {
+		t.Fatalf("got status %+v", j.Status())
+	}
+	// A job stopped by Close can run again, so it isn't dead.
+	if n := atomic.LoadInt32(&letters); n != 0 {
+		t.Fatalf("got %d dead letters, want none", n)
+	}
+}
+
+func TestScheduler_Store(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 3
+	fail := errors.New("fail")
+	ctx := context.Background()
+
+	// The first process gets two attempts in before it is stopped.
+	s := NewScheduler(1)
+	s.Store = store
+	var calls int32
+	s.SubmitKey("job", func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			go s.Close()
+			<-ctx.Done()
+		}
+		return fail
+	}, r)
+	for atomic.LoadInt32(&calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	s.Close()
+
+	st, ok, _ := store.Get(ctx, "job")
+	if !ok || st.Count != 2 {
+		t.Fatalf("got stored state %+v, %v", st, ok)
+	}
+
+	// After a restart, the job only has the attempts it had left.
+	s = NewScheduler(1)
+	defer s.Close()
+	s.Store = store
+	calls = 0
+	j := s.SubmitKey("job", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return fail
+	}, r)
+	if err := j.Wait(ctx); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+	if _, ok, _ := store.Get(ctx, "job"); ok {
+		t.Fatal("finished job left in the store")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build go1.21
+
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithLogger logs each failed attempt that is going to be retried to l at
+// debug level, with the attempt number, the delay before the next attempt
+// and the error. It adds an observer, so it works alongside WithNotify and
+// other observers in any order.
+//
+// Like any Option, it can be applied to a Retrier directly:
+//
+//	retry.WithLogger(logger)(r)
+func WithLogger(l *slog.Logger) Option {
+	return WithObserver(func(attempt int, err error, next time.Duration) {
+		l.LogAttrs(context.Background(), slog.LevelDebug, "retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", next),
+			slog.Any("error", err),
+		)
+	})
+}
@@ -0,0 +1,52 @@
+//go:build go1.21
+
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var notified int
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	},
+		WithFloor(time.Millisecond),
+		WithCeil(time.Millisecond),
+		// The logger must survive a Notify set after it.
+		WithLogger(logger),
+		WithNotify(func(int, error, time.Duration) { notified++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for i, want := range []string{"attempt=1 delay=1ms error=boom", "attempt=2 delay=1ms error=boom"} {
+		if !strings.Contains(lines[i], "level=DEBUG msg=retrying "+want) {
+			t.Errorf("line %d: got %q, want %q", i, lines[i], want)
+		}
+	}
+	if notified != 2 {
+		t.Fatalf("Notify called %d times, want 2", notified)
+	}
+}
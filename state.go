@@ -0,0 +1,41 @@
+package retry
+
+import "time"
+
+// RetrierState is the backoff position of a Retrier, without its
+// configuration. It can be marshaled, e.g. as JSON, so a process can persist
+// it and pick up where it left off after a restart instead of starting again
+// from the smallest delay.
+type RetrierState struct {
+	// Delay is the delay that preceded the most recent attempt.
+	Delay time.Duration `json:"delay"`
+	// Attempt is how far the delay has grown.
+	Attempt int `json:"attempt"`
+	// Count is the number of attempts made, as limited by MaxAttempts.
+	Count int `json:"count"`
+	// Wake is when the most recent attempt was due.
+	Wake time.Time `json:"wake"`
+	// Start is when the first attempt was made, as limited by MaxElapsed.
+	Start time.Time `json:"start"`
+}
+
+// State returns r's backoff position.
+func (r *Retrier) State() RetrierState {
+	return RetrierState{
+		Delay:   r.Delay,
+		Attempt: r.attempt,
+		Count:   r.count,
+		Wake:    r.wake,
+		Start:   r.start,
+	}
+}
+
+// SetState restores a backoff position previously returned by State, so the
+// next Wait continues from it. Decay applies to the time since s.Wake.
+func (r *Retrier) SetState(s RetrierState) {
+	r.Delay = s.Delay
+	r.attempt = s.Attempt
+	r.count = s.Count
+	r.wake = s.Wake
+	r.start = s.Start
+}
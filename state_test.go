@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestState(t *testing.T) {
+	clock := newTestClock()
+	r := New(time.Second, time.Hour)
+	r.Rate = 2
+	r.Clock = clock
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		r.Wait(ctx)
+	}
+
+	b, err := json.Marshal(r.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh retrier, as after a restart, resumes from the saved position.
+	var s RetrierState
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	resumed := New(time.Second, time.Hour)
+	resumed.Rate = 2
+	resumed.Clock = clock
+	resumed.SetState(s)
+	if got, want := resumed.State(), r.State(); got.Attempt != want.Attempt || got.Count != want.Count || !got.Wake.Equal(want.Wake) {
+		t.Fatalf("got state %+v, want %+v", got, want)
+	}
+
+	r.Wait(ctx)
+	resumed.Wait(ctx)
+	if resumed.Delay != r.Delay || r.Delay != 8*time.Second {
+		t.Fatalf("got delay %v, want %v", resumed.Delay, r.Delay)
+	}
+}
@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultStatsBuckets are the histogram bucket bounds a Stats uses when its
+// Buckets are unset.
+var DefaultStatsBuckets = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+}
+
+// Stats collects how long attempts run by Do took and how long was spent
+// waiting before retries, to tell how much of an operation's latency was
+// backoff. It keeps counts, totals and histograms rather than every
+// duration, so it takes fixed memory however long it is used. It is safe for
+// concurrent use, and can be shared by several calls to accumulate their
+// stats.
+type Stats struct {
+	// Buckets are the sorted upper bounds of the histogram buckets. If nil,
+	// DefaultStatsBuckets are used. It must not change once Stats is in use.
+	Buckets []time.Duration
+
+	mu       sync.Mutex
+	attempts DurationStats
+	delays   DurationStats
+}
+
+// DurationStats summarizes a series of durations.
+type DurationStats struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+	// Buckets counts the durations in each histogram bucket. Bucket i holds
+	// those no longer than bound i and longer than any earlier bound; the
+	// extra last bucket holds the rest.
+	Buckets []int
+}
+
+func (s *DurationStats) add(d time.Duration, bounds []time.Duration) {
+	if s.Buckets == nil {
+		s.Buckets = make([]int, len(bounds)+1)
+	}
+	s.Count++
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+	s.Buckets[bucket(d, bounds)]++
+}
+
+func (s DurationStats) clone() DurationStats {
+	s.Buckets = append([]int(nil), s.Buckets...)
+	return s
+}
+
+// StatsSnapshot is a copy of what a Stats has collected.
+type StatsSnapshot struct {
+	// Attempts summarizes the duration of each attempt.
+	Attempts DurationStats
+	// Delays summarizes the time spent waiting before each retry.
+	Delays DurationStats
+	// Bounds are the bucket bounds of both histograms.
+	Bounds []time.Duration
+}
+
+// WithStats sets a Stats to collect into. See Retrier.Stats.
+func WithStats(s *Stats) Option {
+	return func(r *Retrier) { r.Stats = s }
+}
+
+// Snapshot returns what s has collected so far.
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return StatsSnapshot{
+		Attempts: s.attempts.clone(),
+		Delays:   s.delays.clone(),
+		Bounds:   s.bounds(),
+	}
+}
+
+func (s *Stats) bounds() []time.Duration {
+	if s.Buckets == nil {
+		return DefaultStatsBuckets
+	}
+	return s.Buckets
+}
+
+func (s *Stats) addAttempt(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts.add(d, s.bounds())
+}
+
+func (s *Stats) addDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays.add(d, s.bounds())
+}
+
+// AttemptTime returns the total duration of the attempts.
+func (s StatsSnapshot) AttemptTime() time.Duration {
+	return s.Attempts.Total
+}
+
+// DelayTime returns the total time spent waiting before retries.
+func (s StatsSnapshot) DelayTime() time.Duration {
+	return s.Delays.Total
+}
+
+// bucket returns the index of the histogram bucket d falls into.
+func bucket(d time.Duration, bounds []time.Duration) int {
+	i := 0
+	for i < len(bounds) && d > bounds[i] {
+		i++
+	}
+	return i
+}
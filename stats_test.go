@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	clock := newTestClock()
+	stats := Stats{Buckets: []time.Duration{time.Second, time.Minute * 3}}
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		clock.Advance(time.Duration(calls) * time.Second)
+		if calls < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	},
+		WithFloor(time.Minute),
+		WithCeil(time.Hour),
+		WithRate(2),
+		WithStats(&stats),
+		func(r *Retrier) { r.Clock = clock },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := stats.Snapshot()
+	want := StatsSnapshot{
+		Attempts: DurationStats{Count: 3, Total: 6 * time.Second, Max: 3 * time.Second, Buckets: []int{1, 2, 0}},
+		Delays:   DurationStats{Count: 2, Total: 6 * time.Minute, Max: 4 * time.Minute, Buckets: []int{0, 1, 1}},
+		Bounds:   stats.Buckets,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got.AttemptTime() != 6*time.Second || got.DelayTime() != 6*time.Minute {
+		t.Fatalf("got totals %v and %v", got.AttemptTime(), got.DelayTime())
+	}
+}
+
+func TestStats_Empty(t *testing.T) {
+	var stats Stats
+	got := stats.Snapshot()
+	if got.Attempts.Count != 0 || got.DelayTime() != 0 || !reflect.DeepEqual(got.Bounds, DefaultStatsBuckets) {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	bounds := []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}
+	for d, want := range map[time.Duration]int{
+		time.Millisecond:       0,
+		10 * time.Millisecond:  0,
+		50 * time.Millisecond:  1,
+		100 * time.Millisecond: 1,
+		time.Second:            2,
+	} {
+		if got := bucket(d, bounds); got != want {
+			t.Errorf("bucket(%v) = %d, want %d", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+)
+
+// Step is one named step of a Steps pipeline.
+type Step struct {
+	// Name identifies the step, in errors and in the Store.
+	Name string
+
+	// Retrier is cloned to retry the step. If nil, the step backs off as it
+	// would under the package-level Do.
+	Retrier *Retrier
+
+	// Fn performs the step, as under Do.
+	Fn func(ctx context.Context) error
+}
+
+// Steps runs dependent steps in order, each retried under its own policy,
+// such as the stages of provisioning a machine. With a Store, it records
+// each step as it succeeds, so running the pipeline again after a failure or
+// a restart resumes from the first step that hasn't.
+type Steps struct {
+	Steps []Step
+
+	// Store, if set, records completed steps under Key, a slash and the
+	// step's name, using Once. The records are forgotten once every step
+	// has succeeded, so Key can be used again.
+	Store OnceStore
+
+	// Key identifies the run in the Store.
+	Key string
+}
+
+// Run runs the steps not yet completed, stopping at the first one that
+// fails. Its error is wrapped with the step's name.
+func (s *Steps) Run(ctx context.Context) error {
+	for _, step := range s.Steps {
+		r := step.Retrier
+		if r == nil {
+			r = NewRetrier()
+		} else {
+			r = r.Clone()
+		}
+		run := func(ctx context.Context) error { return r.Do(ctx, step.Fn) }
+
+		var err error
+		if s.Store != nil {
+			err = Once(ctx, s.Store, s.key(step), run)
+		} else {
+			err = run(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("retry: step %q: %w", step.Name, err)
+		}
+	}
+	if s.Store != nil {
+		for _, step := range s.Steps {
+			if err := s.Store.Forget(ctx, s.key(step)); err != nil {
+				return fmt.Errorf("retry: step %q: %w", step.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Steps) key(step Step) string {
+	return s.Key + "/" + step.Name
+}
@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSteps(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	fail := errors.New("fail")
+	r := New(time.Millisecond, time.Millisecond)
+	r.MaxAttempts = 2
+
+	var created, configured int
+	broken := true
+	s := &Steps{
+		Store: store,
+		Key:   "vm-1",
+		Steps: []Step{
+			{Name: "create", Retrier: r, Fn: func(ctx context.Context) error {
+				created++
+				return nil
+			}},
+			{Name: "configure", Retrier: r, Fn: func(ctx context.Context) error {
+				configured++
+				if broken {
+					return fail
+				}
+				return nil
+			}},
+		},
+	}
+
+	ctx := context.Background()
+	if err := s.Run(ctx); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	if created != 1 || configured != 2 {
+		t.Fatalf("got %d creates and %d configures, want 1 and 2", created, configured)
+	}
+
+	// The second run resumes at the step that failed.
+	broken = false
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if created != 1 || configured != 3 {
+		t.Fatalf("got %d creates and %d configures, want 1 and 3", created, configured)
+	}
+	if done, _ := store.Done(ctx, "vm-1/create"); done {
+		t.Fatal("records kept after the run completed")
+	}
+}
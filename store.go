@@ -0,0 +1,185 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists retrier states by ID, so retries can survive a restart.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the state stored for id, and false if there is none.
+	Get(ctx context.Context, id string) (RetrierState, bool, error)
+	// Put stores the state for id, replacing any already there.
+	Put(ctx context.Context, id string, s RetrierState) error
+	// Delete removes the state for id, if any.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store and OnceStore that keeps states and records in
+// memory. It is mostly useful in tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]RetrierState
+	done   map[string]bool
+}
+
+var (
+	_ Store     = (*MemoryStore)(nil)
+	_ OnceStore = (*MemoryStore)(nil)
+)
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		states: make(map[string]RetrierState),
+		done:   make(map[string]bool),
+	}
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(ctx context.Context, id string) (RetrierState, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.states[id]
+	return s, ok, nil
+}
+
+// Put implements Store.
+func (m *MemoryStore) Put(ctx context.Context, id string, s RetrierState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[id] = s
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, id)
+	return nil
+}
+
+// Done implements OnceStore.
+func (m *MemoryStore) Done(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done[key], nil
+}
+
+// MarkDone implements OnceStore.
+func (m *MemoryStore) MarkDone(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done[key] = true
+	return nil
+}
+
+// Forget implements OnceStore.
+func (m *MemoryStore) Forget(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.done, key)
+	return nil
+}
+
+// FileStore is a Store that keeps each state as a JSON file in Dir, which
+// must exist. As an OnceStore, it marks each key done with an empty file
+// beside them.
+type FileStore struct {
+	Dir string
+}
+
+var (
+	_ Store     = FileStore{}
+	_ OnceStore = FileStore{}
+)
+
+func (f FileStore) path(id string) string {
+	return filepath.Join(f.Dir, url.PathEscape(id)+".json")
+}
+
+func (f FileStore) donePath(key string) string {
+	return filepath.Join(f.Dir, url.PathEscape(key)+".done")
+}
+
+// Get implements Store.
+func (f FileStore) Get(ctx context.Context, id string) (RetrierState, bool, error) {
+	var s RetrierState
+	b, err := os.ReadFile(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, false, nil
+	}
+	if err != nil {
+		return s, false, err
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, false, err
+	}
+	return s, true, nil
+}
+
+// Put implements Store. The file is replaced atomically, so a crash can't
+// leave a partial state behind.
+func (f FileStore) Put(ctx context.Context, id string, s RetrierState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(id))
+}
+
+// Delete implements Store.
+func (f FileStore) Delete(ctx context.Context, id string) error {
+	err := os.Remove(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Done implements OnceStore.
+func (f FileStore) Done(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(f.donePath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkDone implements OnceStore.
+func (f FileStore) MarkDone(ctx context.Context, key string) error {
+	file, err := os.OpenFile(f.donePath(key), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Forget implements OnceStore.
+func (f FileStore) Forget(ctx context.Context, key string) error {
+	err := os.Remove(f.donePath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
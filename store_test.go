@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, ok, err := s.Get(ctx, "a/b"); err != nil || ok {
+		t.Fatalf("Get of missing ID: ok %v, err %v", ok, err)
+	}
+
+	want := RetrierState{Delay: time.Second, Attempt: 2, Count: 3, Wake: time.Unix(100, 0).UTC()}
+	if err := s.Put(ctx, "a/b", want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := s.Get(ctx, "a/b")
+	if err != nil || !ok {
+		t.Fatalf("Get: ok %v, err %v", ok, err)
+	}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if err := s.Delete(ctx, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a/b"); err != nil {
+		t.Fatalf("Delete of missing ID: %v", err)
+	}
+	if _, ok, _ := s.Get(ctx, "a/b"); ok {
+		t.Fatal("state survived Delete")
+	}
+}
+
+// testOnceStore checks that s keeps Once's records apart from states.
+func testOnceStore(t *testing.T, s interface {
+	Store
+	OnceStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "a/b", RetrierState{Attempt: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := s.Done(ctx, "a/b"); err != nil || done {
+		t.Fatalf("Done with only a state: done %v, err %v", done, err)
+	}
+	if err := s.MarkDone(ctx, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := s.Done(ctx, "a/b"); err != nil || !done {
+		t.Fatalf("Done after MarkDone: done %v, err %v", done, err)
+	}
+	if got, ok, err := s.Get(ctx, "a/b"); err != nil || !ok || got.Attempt != 1 {
+		t.Fatalf("state changed by MarkDone: got %+v, ok %v, err %v", got, ok, err)
+	}
+
+	if err := s.Forget(ctx, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Forget(ctx, "a/b"); err != nil {
+		t.Fatalf("Forget of missing key: %v", err)
+	}
+	if done, _ := s.Done(ctx, "a/b"); done {
+		t.Fatal("record survived Forget")
+	}
+	if _, ok, _ := s.Get(ctx, "a/b"); !ok {
+		t.Fatal("state removed by Forget")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+	testOnceStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	testStore(t, FileStore{Dir: t.TempDir()})
+	testOnceStore(t, FileStore{Dir: t.TempDir()})
+}
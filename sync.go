@@ -0,0 +1,65 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SyncRetrier is a Retrier that is safe for concurrent use.
+//
+// All goroutines share a single backoff: a Wait from any of them advances
+// the delay for every other, and a Reset from any of them resets it. This
+// lets a pool of workers talking to the same dependency back off together
+// rather than each hammering it on its own schedule.
+//
+// The retrier's Breaker, Budget, Limiter and Coordinator are consulted by
+// every Wait, concurrently, so any custom implementations must be safe for
+// concurrent use. The ones in this package are.
+type SyncRetrier struct {
+	mu sync.Mutex
+	r  *Retrier
+}
+
+// NewSync wraps r for concurrent use. r must not be used directly afterwards.
+func NewSync(r *Retrier) *SyncRetrier {
+	return &SyncRetrier{r: r}
+}
+
+// Wait is like Retrier.Wait. Concurrent callers sleep in parallel; only the
+// computation of the delay is serialized.
+func (s *SyncRetrier) Wait(ctx context.Context) bool {
+	return s.WaitErr(ctx) == nil
+}
+
+// WaitErr is like Retrier.WaitErr.
+func (s *SyncRetrier) WaitErr(ctx context.Context) error {
+	s.mu.Lock()
+	d, err := s.r.next()
+	retry := s.r.count > 1
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	// Callers sleep concurrently, so they can't share the retrier's timer.
+	return s.r.pauseOn(ctx, d, retry, s.sleep)
+}
+
+func (s *SyncRetrier) sleep(ctx context.Context, d time.Duration) bool {
+	return sleepOn(ctx, s.r.clock(), d)
+}
+
+// Reset resets the underlying retrier to its initial state.
+func (s *SyncRetrier) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.r.Reset()
+}
+
+// Delay returns the current delay between attempts.
+func (s *SyncRetrier) Delay() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Delay
+}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSyncRetrier(t *testing.T) {
+	t.Parallel()
+
+	s := NewSync(New(time.Millisecond, time.Millisecond*10))
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if !s.Wait(ctx) {
+					t.Errorf("attempt not allowed")
+				}
+				if j%3 == 0 {
+					s.Reset()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if d := s.Delay(); d > time.Millisecond*10 {
+		t.Fatalf("delay out of bounds: %v", d)
+	}
+}
+
+func TestSyncRetrier_Shared(t *testing.T) {
+	t.Parallel()
+
+	s := NewSync(New(time.Hour, time.Hour))
+
+	// The first Wait is immediate, every one after it shares the backoff.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if !s.Wait(ctx) {
+		t.Fatalf("attempt not allowed")
+	}
+	if s.Wait(ctx) {
+		t.Fatalf("second attempt should have backed off")
+	}
+}
+
+func TestSyncRetrier_Breaker(t *testing.T) {
+	t.Parallel()
+
+	r := New(time.Millisecond, time.Millisecond)
+	r.Breaker = NewBreaker(1, 1, time.Hour)
+	r.Breaker.Record(errors.New("down"))
+	s := NewSync(r)
+
+	if err := s.WaitErr(context.Background()); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("got %v, want %v", err, ErrBreakerOpen)
+	}
+}
@@ -0,0 +1,41 @@
+package retry
+
+import "errors"
+
+// Errors returned by Validate. The error returned may wrap several of them.
+var (
+	// ErrRate means Rate is not above 1, so the delay never grows.
+	ErrRate = errors.New("retry: rate must be greater than 1")
+	// ErrFloorAboveCeil means Floor is greater than Ceil.
+	ErrFloorAboveCeil = errors.New("retry: floor must not exceed ceil")
+	// ErrNegativeJitter means Jitter is negative.
+	ErrNegativeJitter = errors.New("retry: jitter must not be negative")
+	// ErrNegative means a delay or limit is negative.
+	ErrNegative = errors.New("retry: durations and limits must not be negative")
+)
+
+// Validate reports configurations that can't behave as intended, which
+// Wait would otherwise follow without complaint. Use errors.Is to tell the
+// problems apart.
+func (r *Retrier) Validate() error {
+	var errs []error
+	if r.Floor < 0 || r.Ceil < 0 || r.MaxAttempts < 0 || r.MaxElapsed < 0 || r.Decay < 0 || r.MinDelay < 0 || r.HotLoopRate < 0 {
+		errs = append(errs, ErrNegative)
+	}
+	if r.Floor > r.Ceil {
+		errs = append(errs, ErrFloorAboveCeil)
+	}
+	if !(r.Rate > 1) {
+		errs = append(errs, ErrRate)
+	}
+	if r.Jitter < 0 {
+		errs = append(errs, ErrNegativeJitter)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports whether the retrier p configures is valid. See
+// Retrier.Validate.
+func (p Policy) Validate() error {
+	return p.Retrier().Validate()
+}
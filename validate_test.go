@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	if err := New(time.Second, time.Minute).Validate(); err != nil {
+		t.Fatalf("default retrier invalid: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		r    *Retrier
+		want []error
+	}{
+		"rate": {
+			&Retrier{Floor: time.Second, Ceil: time.Minute, Rate: 1},
+			[]error{ErrRate},
+		},
+		"NaN rate": {
+			&Retrier{Floor: time.Second, Ceil: time.Minute, Rate: math.NaN()},
+			[]error{ErrRate},
+		},
+		"floor above ceil": {
+			New(time.Minute, time.Second),
+			[]error{ErrFloorAboveCeil},
+		},
+		"jitter": {
+			&Retrier{Floor: time.Second, Ceil: time.Minute, Rate: 2, Jitter: -0.1},
+			[]error{ErrNegativeJitter},
+		},
+		"several": {
+			&Retrier{Floor: -time.Second, Ceil: time.Minute, Rate: 0.5},
+			[]error{ErrNegative, ErrRate},
+		},
+	} {
+		err := tc.r.Validate()
+		for _, want := range tc.want {
+			if !errors.Is(err, want) {
+				t.Errorf("%s: got %v, want %v", name, err, want)
+			}
+		}
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	// A zero rate means the default, so it's valid.
+	if err := (Policy{Floor: time.Second, Ceil: time.Minute}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Policy{Floor: time.Minute, Ceil: time.Second}).Validate(); !errors.Is(err, ErrFloorAboveCeil) {
+		t.Fatalf("got %v, want %v", err, ErrFloorAboveCeil)
+	}
+}